@@ -1,16 +1,27 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"gopkg.in/yaml.v2"
 	"io"
+	"io/ioutil"
 	"math"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"vgo/log"
 )
 
 // abortIndex 允许中间件中断执行
@@ -18,13 +29,16 @@ const abortIndex int8 = math.MaxInt8 / 2
 
 type H map[string]interface{}
 
+var _ context.Context = &Context{}
+
 // Context is the most important part of vgo. It allows us to pass variables
 // between middleware, manage the flow, validate the JSON of a request and
 // render a JSON response for example
 type Context struct {
 	// origin objects
-	Writer http.ResponseWriter
-	Req    *http.Request
+	writermem responseWriter
+	Writer    ResponseWriter
+	Req       *http.Request
 	// request info
 	Path   string
 	Method string
@@ -39,7 +53,8 @@ type Context struct {
 	mu sync.RWMutex
 
 	// keys is a k/v pair exclusively for the context of each request.
-	keys map[string]interface{}
+	// Keys are either strings set with Set or typed keys created by NewKey.
+	keys map[interface{}]interface{}
 
 	// Errors is a list of errors attached to all the handlers/middlewares who used this context.
 	Errors errorMsgs
@@ -54,9 +69,17 @@ type Context struct {
 	// or PUT body parameters.
 	formCache url.Values
 
+	engine *Engine
+
 	// sameSite allows a server to define a cookie attribute making it impossible for
 	// the browser to send this cookie along with cross-site requests.
 	sameSite http.SameSite
+
+	// fullPath is the pattern of the matched route, e.g. /hello/:name
+	fullPath string
+
+	// rawData caches the request body read by GetRawData.
+	rawData []byte
 }
 
 /************************************/
@@ -65,30 +88,131 @@ type Context struct {
 
 // NewContext context的构造函数
 func NewContext(w http.ResponseWriter, req *http.Request) *Context {
-	return &Context{
-		Writer: w,
+	c := &Context{
 		Req:    req,
 		Path:   req.URL.Path,
 		Method: req.Method,
 		index:  -1,
 	}
+	c.writermem.reset(w)
+	c.Writer = &c.writermem
+	return c
 }
 
+// reset 清空请求相关的字段，使context可以放回对象池复用
 func (c *Context) reset() {
 	c.Req = nil
 	c.Writer = nil
 	c.Path = ""
 	c.Method = ""
 	c.Params = nil
+	c.StatusCode = 0
 
 	c.Handlers = nil
 	c.index = -1
+	c.mu.Lock()
 	c.keys = nil
+	c.mu.Unlock()
+	c.Errors = c.Errors[:0]
+	c.Accepted = nil
+	c.queryCache = nil
+	c.formCache = nil
+	c.sameSite = 0
+	c.fullPath = ""
+	c.rawData = nil
+}
+
+// Copy returns a copy of the current context that can be safely used outside the request's scope.
+// This has to be used when the context has to be passed to a goroutine, because the original
+// context is reset and reused by another request once the handlers return.
+// Params and keys are deep-copied and the request is kept, while the writer is detached:
+// writing to the copy returns ErrResponseDetached instead of touching the finished response.
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Writer:     &detachedWriter{header: make(http.Header), status: c.Writer.Status()},
+		Req:        c.Req,
+		Path:       c.Path,
+		Method:     c.Method,
+		StatusCode: c.StatusCode,
+		index:      abortIndex,
+		engine:     c.engine,
+		sameSite:   c.sameSite,
+		fullPath:   c.fullPath,
+		rawData:    c.rawData,
+	}
+
+	if c.Params != nil {
+		cp.Params = make(map[string]string, len(c.Params))
+		for k, v := range c.Params {
+			cp.Params[k] = v
+		}
+	}
+
+	c.mu.RLock()
+	if c.keys != nil {
+		cp.keys = make(map[interface{}]interface{}, len(c.keys))
+		for k, v := range c.keys {
+			cp.keys[k] = v
+		}
+	}
+	c.mu.RUnlock()
+
+	if c.Accepted != nil {
+		cp.Accepted = append([]string(nil), c.Accepted...)
+	}
+	return cp
 }
 
-// Copy returns a copy of the current context that can be safely used outside the request's scope
-// This has to be used when the context has to be passed to a goroutine TODO why
-func (c *Context) Copy() *Context {
+// detachedWriter 复制出的Context使用的writer，写入响应体总是返回 ErrResponseDetached，
+// 避免后台goroutine写入已经结束（或已被其他请求复用）的响应
+type detachedWriter struct {
+	header http.Header
+	status int
+}
+
+var _ ResponseWriter = &detachedWriter{}
+
+func (w *detachedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *detachedWriter) Write([]byte) (int, error) {
+	return 0, ErrResponseDetached
+}
+
+func (w *detachedWriter) WriteString(string) (int, error) {
+	return 0, ErrResponseDetached
+}
+
+func (w *detachedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *detachedWriter) WriteHeaderNow() {}
+
+func (w *detachedWriter) Status() int {
+	return w.status
+}
+
+func (w *detachedWriter) Size() int {
+	return noWritten
+}
+
+func (w *detachedWriter) Written() bool {
+	return false
+}
+
+func (w *detachedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, ErrResponseDetached
+}
+
+func (w *detachedWriter) Flush() {}
+
+func (w *detachedWriter) CloseNotify() <-chan bool {
+	return nil
+}
+
+func (w *detachedWriter) Pusher() http.Pusher {
 	return nil
 }
 
@@ -173,7 +297,7 @@ func (c *Context) AbortWithError(code int, err error) *Error {
 // print a log, or append it in the HTTP response.
 // Error will panic if err is nil.
 func (c *Context) Error(err error) *Error {
-	if err != nil {
+	if err == nil {
 		panic("err is nil")
 	}
 
@@ -193,14 +317,53 @@ func (c *Context) Error(err error) *Error {
 /************ INPUT DATA ************/
 /************************************/
 
-// PostForm  获取请求体中的请求参数 - Form表单
-func (c *Context) PostForm(key string) string {
-	return c.Req.FormValue(key)
-}
-
 // Query 获取请求体中的请求参数 - url
+// It is shortcut for `c.Req.URL.Query().Get(key)`, but the query is only parsed once per request.
+//
+//	GET /path?id=1234&name=Manu&value=
+//	c.Query("id") == "1234"
+//	c.Query("name") == "Manu"
+//	c.Query("value") == ""
+//	c.Query("wtf") == ""
 func (c *Context) Query(key string) string {
-	return c.Req.URL.Query().Get(key)
+	value, _ := c.GetQuery(key)
+	return value
+}
+
+// DefaultQuery returns the keyed url query value if it exists,
+// otherwise it returns the specified defaultValue string.
+//
+//	GET /?name=Manu&lastname=
+//	c.DefaultQuery("name", "unknown") == "Manu"
+//	c.DefaultQuery("id", "none") == "none"
+//	c.DefaultQuery("lastname", "none") == ""
+func (c *Context) DefaultQuery(key, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetQuery is like Query(), it returns the keyed url query value
+// if it exists `(value, true)` (even when the value is an empty string),
+// otherwise it returns `("", false)`.
+//
+//	GET /?name=Manu&lastname=
+//	("Manu", true) == c.GetQuery("name")
+//	("", false) == c.GetQuery("id")
+//	("", true) == c.GetQuery("lastname")
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], ok
+	}
+	return "", false
+}
+
+// QueryArray returns a slice of strings for a given query key.
+// The length of the slice depends on the number of params with the given key.
+func (c *Context) QueryArray(key string) []string {
+	values, _ := c.GetQueryArray(key)
+	return values
 }
 
 func (c *Context) initQueryCache() {
@@ -213,54 +376,302 @@ func (c *Context) initQueryCache() {
 	}
 }
 
+// GetQueryArray returns a slice of strings for a given query key, plus
+// a boolean value whether at least one value exists for the given key.
+func (c *Context) GetQueryArray(key string) ([]string, bool) {
+	c.initQueryCache()
+	if values, ok := c.queryCache[key]; ok && len(values) > 0 {
+		return values, true
+	}
+	return []string{}, false
+}
+
+// QueryMap returns a map for a given query key.
+//
+//	GET /?ids[a]=1&ids[b]=2
+//	c.QueryMap("ids") == map[string]string{"a": "1", "b": "2"}
+func (c *Context) QueryMap(key string) map[string]string {
+	dicts, _ := c.GetQueryMap(key)
+	return dicts
+}
+
+// GetQueryMap returns a map for a given query key, plus a boolean value
+// whether at least one value exists for the given key.
+func (c *Context) GetQueryMap(key string) (map[string]string, bool) {
+	c.initQueryCache()
+	return c.get(c.queryCache, key)
+}
+
+// PostForm  获取请求体中的请求参数 - Form表单
+// Only the body of a urlencoded or multipart form is read, values in the url query are ignored.
+func (c *Context) PostForm(key string) string {
+	value, _ := c.GetPostForm(key)
+	return value
+}
+
+// DefaultPostForm returns the specified key from a POST urlencoded form or multipart form
+// when it exists, otherwise it returns the specified defaultValue string.
+// See: PostForm() and GetPostForm() for further information.
+func (c *Context) DefaultPostForm(key, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm is like PostForm(key). It returns the specified key from a POST urlencoded
+// form or multipart form when it exists `(value, true)` (even when the value is an empty string),
+// otherwise it returns ("", false).
+// For example, during a PATCH request to update the user's email:
+//
+//	email=mail@example.com  -->  ("mail@example.com", true) := GetPostForm("email") // set email to "mail@example.com"
+//	email=                  -->  ("", true) := GetPostForm("email") // set email to ""
+//	                        -->  ("", false) := GetPostForm("email") // do nothing with email
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if values, ok := c.GetPostFormArray(key); ok {
+		return values[0], ok
+	}
+	return "", false
+}
+
+// PostFormArray returns a slice of strings for a given form key.
+// The length of the slice depends on the number of params with the given key.
+func (c *Context) PostFormArray(key string) []string {
+	values, _ := c.GetPostFormArray(key)
+	return values
+}
+
+// initFormCache 解析请求体中的表单并缓存，urlencoded 与 multipart 表单的字段都会被缓存
 func (c *Context) initFormCache() {
 	if c.formCache == nil {
 		c.formCache = make(url.Values)
 		req := c.Req
-		c.formCache = req.PostForm
+		if req == nil {
+			return
+		}
+		// 非multipart请求返回 http.ErrNotMultipart，此时urlencoded表单已经被 ParseForm 解析
+		if err := req.ParseMultipartForm(c.maxMultipartMemory()); err != nil && err != http.ErrNotMultipart {
+			log.Warn("error on parse multipart form array: ", err)
+		}
+		if req.PostForm != nil {
+			c.formCache = req.PostForm
+		}
+	}
+}
+
+// GetPostFormArray returns a slice of strings for a given form key, plus
+// a boolean value whether at least one value exists for the given key.
+func (c *Context) GetPostFormArray(key string) ([]string, bool) {
+	c.initFormCache()
+	if values, ok := c.formCache[key]; ok && len(values) > 0 {
+		return values, true
+	}
+	return []string{}, false
+}
+
+// PostFormMap returns a map for a given form key.
+//
+//	POST ids[a]=1&ids[b]=2
+//	c.PostFormMap("ids") == map[string]string{"a": "1", "b": "2"}
+func (c *Context) PostFormMap(key string) map[string]string {
+	dicts, _ := c.GetPostFormMap(key)
+	return dicts
+}
+
+// GetPostFormMap returns a map for a given form key, plus a boolean value
+// whether at least one value exists for the given key.
+func (c *Context) GetPostFormMap(key string) (map[string]string, bool) {
+	c.initFormCache()
+	return c.get(c.formCache, key)
+}
+
+// get is an internal method and returns a map which satisfy conditions.
+func (c *Context) get(m map[string][]string, key string) (map[string]string, bool) {
+	dicts := make(map[string]string)
+	exist := false
+	for k, v := range m {
+		if i := strings.IndexByte(k, '['); i >= 1 && k[0:i] == key {
+			if j := strings.IndexByte(k[i+1:], ']'); j >= 1 {
+				exist = true
+				dicts[k[i+1:][:j]] = v[0]
+			}
+		}
+	}
+	return dicts, exist
+}
+
+// FullPath returns a matched route full path. For not found routes
+// returns an empty string.
+//
+//	router.GET("/user/:id", func(c *core.Context) {
+//	    c.FullPath() == "/user/:id" // true
+//	})
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+// GetHeader returns value from request headers.
+func (c *Context) GetHeader(key string) string {
+	return c.Req.Header.Get(key)
+}
+
+// ContentType returns the Content-Type header of the request, without parameters such as charset.
+func (c *Context) ContentType() string {
+	return filterFlags(c.GetHeader("Content-Type"))
+}
+
+// IsWebsocket returns true if the request headers indicate that a websocket
+// handshake is being initiated by the client.
+func (c *Context) IsWebsocket() bool {
+	return headerContainsToken(c.GetHeader("Connection"), "upgrade") &&
+		strings.EqualFold(strings.TrimSpace(c.GetHeader("Upgrade")), "websocket")
+}
+
+// GetRawData returns the request body. The body is read only once and cached,
+// and c.Req.Body is replaced with a reader over the cached data, so that both middleware
+// and binders can read it. Bodies larger than Engine.MaxRawDataSize are rejected with ErrBodyTooLarge,
+// in which case c.Req.Body still yields the whole body, including the part that was already read.
+func (c *Context) GetRawData() ([]byte, error) {
+	if c.rawData != nil {
+		return c.rawData, nil
+	}
+	if c.Req.Body == nil || c.Req.Body == http.NoBody {
+		c.rawData = []byte{}
+		return c.rawData, nil
+	}
+
+	var limit int64
+	if c.engine != nil {
+		limit = c.engine.MaxRawDataSize
+	}
+	var reader io.Reader = c.Req.Body
+	if limit > 0 {
+		reader = io.LimitReader(c.Req.Body, limit+1)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		// 放回已经读取的部分，之后的读取依然能拿到完整的请求体
+		body := c.Req.Body
+		c.Req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), body), body}
+		return nil, ErrBodyTooLarge
 	}
+
+	c.rawData = data
+	c.Req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
 }
 
 // FormFile returns the first file for the provided form key.
+// The multipart form is parsed lazily on first use.
 func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
-	if c.Req.MultipartForm == nil {
-		return nil, Error{
-			Type: ErrorTypePrivate,
-			Meta: "request.multipartFrom is nil",
-		}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	if fhs := form.File[name]; len(fhs) > 0 {
+		return fhs[0], nil
 	}
+	return nil, http.ErrMissingFile
+}
 
-	file, fileHeader, err := c.Req.FormFile(name)
+// FormFiles returns all the files uploaded under the provided form key, e.g. <input type="file" multiple>.
+func (c *Context) FormFiles(name string) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
 	if err != nil {
 		return nil, err
 	}
-	_ = file.Close()
-	return fileHeader, err
+	if fhs := form.File[name]; len(fhs) > 0 {
+		return fhs, nil
+	}
+	return nil, http.ErrMissingFile
 }
 
 // MultipartForm is the parsed multipart form, including file uploads.
+// Up to Engine.MaxMultipartMemory bytes of the body are kept in memory, the rest is stored
+// in temporary files which are removed once the request has been handled.
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	return nil, nil
+	if c.Req.MultipartForm == nil {
+		if err := c.Req.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			return nil, err
+		}
+	}
+	return c.Req.MultipartForm, nil
 }
 
-// SaveUploadFile uploads the form file to specific dst.
-func (c *Context) SaveUploadFile(file *multipart.FileHeader, dst string) error {
+// maxMultipartMemory 返回解析multipart表单时允许使用的内存上限
+func (c *Context) maxMultipartMemory() int64 {
+	if c.engine != nil && c.engine.MaxMultipartMemory > 0 {
+		return c.engine.MaxMultipartMemory
+	}
+	return defaultMultipartMemory
+}
+
+// SaveUploadedFile uploads the form file to specific dst.
+// Missing parent directories of dst are created, and files larger than Engine.MaxUploadFileSize
+// are rejected with ErrFileTooLarge. A partially written dst is removed when saving fails.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) (err error) {
+	var limit int64
+	if c.engine != nil {
+		limit = c.engine.MaxUploadFileSize
+	}
+	if limit > 0 && file.Size > limit {
+		return ErrFileTooLarge
+	}
+
 	src, err := file.Open()
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
 	out, err := os.Create(dst)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	defer func() {
+		if cErr := out.Close(); err == nil {
+			err = cErr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
 
-	_, err = io.Copy(out, src)
+	var reader io.Reader = src
+	if limit > 0 {
+		// 防止FileHeader.Size与实际内容不一致
+		reader = io.LimitReader(src, limit+1)
+	}
+	n, err := io.Copy(out, reader)
+	if err == nil && limit > 0 && n > limit {
+		err = ErrFileTooLarge
+	}
 	return err
 }
 
+// SaveUploadFile uploads the form file to specific dst.
+//
+// Deprecated: use SaveUploadedFile instead.
+func (c *Context) SaveUploadFile(file *multipart.FileHeader, dst string) error {
+	return c.SaveUploadedFile(file, dst)
+}
+
+// removeMultipartFiles 删除解析multipart表单时产生的临时文件
+func (c *Context) removeMultipartFiles() {
+	if c.Req != nil && c.Req.MultipartForm != nil {
+		_ = c.Req.MultipartForm.RemoveAll()
+	}
+}
+
 // Status 设置响应状态码
 func (c *Context) Status(code int) {
 	c.StatusCode = code
@@ -272,6 +683,43 @@ func (c *Context) SetHeader(key string, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
+// SetSameSite with cookie
+func (c *Context) SetSameSite(sameSite http.SameSite) {
+	c.sameSite = sameSite
+}
+
+// SetCookie adds a Set-Cookie header to the ResponseWriter's headers.
+// The provided cookie must have a valid Name. Invalid cookies may be
+// silently dropped.
+func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		SameSite: c.sameSite,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	})
+}
+
+// Cookie returns the named cookie provided in the request or
+// ErrNoCookie if not found. And return the named cookie is unescaped.
+// If multiple cookies match the given name, only one cookie will
+// be returned.
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Req.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	val, _ := url.QueryUnescape(cookie.Value)
+	return val, nil
+}
+
 // String 返回字符流数据
 func (c *Context) String(code int, format string, values ...interface{}) {
 	c.SetHeader("Content-Type", "text/plain")
@@ -289,17 +737,65 @@ func (c *Context) JSON(code int, obj interface{}) {
 	}
 }
 
+// XML 返回XML格式数据
+func (c *Context) XML(code int, obj interface{}) {
+	c.SetHeader("Content-Type", "application/xml; charset=utf-8")
+	c.Status(code)
+	encoder := xml.NewEncoder(c.Writer)
+	if err := encoder.Encode(obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+	}
+}
+
+// YAML 返回YAML格式数据
+func (c *Context) YAML(code int, obj interface{}) {
+	bytes, err := yaml.Marshal(obj)
+	if err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", "application/x-yaml; charset=utf-8")
+	c.Status(code)
+	c.Writer.Write(bytes)
+}
+
 // Data 返回字节流数据
 func (c *Context) Data(code int, data []byte) {
 	c.Status(code)
 	c.Writer.Write(data)
 }
 
-// HTML 返回HTML数据，在目前前后端分离的体系中，不常用
-func (c *Context) HTML(code int, html string) {
-	c.SetHeader("Content-Type", "text/html")
+// DataFromReader 从reader中流式返回数据，适用于大文件等无法一次读入内存的响应体，
+// contentLength 小于0时不设置 Content-Length
+func (c *Context) DataFromReader(code int, contentLength int64, contentType string, reader io.Reader, extraHeaders map[string]string) {
+	header := c.Writer.Header()
+	for key, value := range extraHeaders {
+		header.Set(key, value)
+	}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if contentLength >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+	}
+	c.Status(code)
+	_, _ = io.Copy(c.Writer, reader)
+}
+
+// HTML 渲染指定名称的HTML模板，模板需要先通过 Engine.LoadHTMLGlob 等方法加载
+func (c *Context) HTML(code int, name string, obj interface{}) {
+	if c.engine == nil {
+		http.Error(c.Writer, "html/template: context is not bound to an engine", 500)
+		return
+	}
+	var buf bytes.Buffer
+	if err := c.engine.executeHTML(&buf, name, obj); err != nil {
+		http.Error(c.Writer, err.Error(), 500)
+		return
+	}
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
 	c.Status(code)
-	c.Writer.Write([]byte(html))
+	c.Writer.Write(buf.Bytes())
 }
 
 // Fail 返回失败状态
@@ -321,25 +817,44 @@ func (c *Context) Param(key string) string {
 	return value
 }
 
-// Set is used to store a k/v pair exclusively for this context.
-// It also lazy initializes c.Keys if it was not used previously.
-func (c *Context) Set(key string, value interface{}) {
+// setKey 在写锁保护下保存键值对，并在第一次使用时初始化 c.keys
+func (c *Context) setKey(key, value interface{}) {
 	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.keys == nil {
-		c.keys = make(map[string]interface{})
+		c.keys = make(map[interface{}]interface{})
 	}
-
 	c.keys[key] = value
-	c.mu.Unlock()
+}
+
+// getKey 在读锁保护下读取键值对
+func (c *Context) getKey(key interface{}) (value interface{}, exists bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, exists = c.keys[key]
+	return
+}
+
+// Set is used to store a k/v pair exclusively for this context.
+// It also lazy initializes c.Keys if it was not used previously.
+// Middleware shared between packages should prefer typed keys created by NewKey,
+// which cannot collide with each other nor with string keys.
+func (c *Context) Set(key string, value interface{}) {
+	c.setKey(key, value)
 }
 
 // Get returns the value for the given key, ie: (value, true).
 // If the value does not exists it returns (nil, false)
 func (c *Context) Get(key string) (value interface{}, exists bool) {
-	c.mu.RLocker()
-	value, exists = c.keys[key]
-	c.mu.RUnlock()
-	return
+	return c.getKey(key)
+}
+
+// MustGet returns the value for the given key if it exists, otherwise it panics.
+func (c *Context) MustGet(key string) interface{} {
+	if value, exists := c.Get(key); exists {
+		return value
+	}
+	panic("Key \"" + key + "\" does not exist")
 }
 
 // GetString returns the value associated with the key as a string.
@@ -405,3 +920,102 @@ func (c *Context) GetTime(key string) (t time.Time) {
 	}
 	return
 }
+
+// GetDuration returns the value associated with the key as a duration.
+func (c *Context) GetDuration(key string) (d time.Duration) {
+	if val, ok := c.Get(key); ok && val != nil {
+		d, _ = val.(time.Duration)
+	}
+	return
+}
+
+// GetStringSlice returns the value associated with the key as a slice of strings.
+func (c *Context) GetStringSlice(key string) (ss []string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		ss, _ = val.([]string)
+	}
+	return
+}
+
+// GetStringMap returns the value associated with the key as a map of interfaces.
+func (c *Context) GetStringMap(key string) (sm map[string]interface{}) {
+	if val, ok := c.Get(key); ok && val != nil {
+		sm, _ = val.(map[string]interface{})
+	}
+	return
+}
+
+// GetStringMapString returns the value associated with the key as a map of strings.
+func (c *Context) GetStringMapString(key string) (sms map[string]string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		sms, _ = val.(map[string]string)
+	}
+	return
+}
+
+// GetStringMapStringSlice returns the value associated with the key as a map to a slice of strings.
+func (c *Context) GetStringMapStringSlice(key string) (smss map[string][]string) {
+	if val, ok := c.Get(key); ok && val != nil {
+		smss, _ = val.(map[string][]string)
+	}
+	return
+}
+
+/************************************/
+/***** GOLANG.ORG/X/NET/CONTEXT *****/
+/************************************/
+
+// ContextKey is the key that a Context returns itself for.
+const ContextKey = "_vgo/contextkey"
+
+// hasRequestContext returns whether c.Req has Context.
+func (c *Context) hasRequestContext() bool {
+	return c.Req != nil && c.Req.Context() != nil
+}
+
+// Deadline returns the deadline of the request context,
+// or that there is no deadline (ok==false) when c.Req has no Context.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if !c.hasRequestContext() {
+		return
+	}
+	return c.Req.Context().Deadline()
+}
+
+// Done returns nil (chan which will wait forever) when c.Req has no Context.
+// Otherwise the channel is closed when the client disconnects or the request is canceled,
+// so *Context can be passed to calls that should stop with the request.
+func (c *Context) Done() <-chan struct{} {
+	if !c.hasRequestContext() {
+		return nil
+	}
+	return c.Req.Context().Done()
+}
+
+// Err returns nil when c.Req has no Context, otherwise the error of the request context.
+func (c *Context) Err() error {
+	if !c.hasRequestContext() {
+		return nil
+	}
+	return c.Req.Context().Err()
+}
+
+// Value returns the value associated with this context for key, or nil
+// if no value is associated with key. Successive calls to Value with
+// the same key returns the same result.
+// String keys are looked up in the keys set with Set first, then the request
+// context is consulted when Engine.ContextWithFallback is true.
+func (c *Context) Value(key interface{}) interface{} {
+	if key == ContextKey {
+		return c
+	}
+	if keyAsString, ok := key.(string); ok {
+		if val, exists := c.Get(keyAsString); exists {
+			return val
+		}
+	}
+	if c.engine == nil || !c.engine.ContextWithFallback || !c.hasRequestContext() {
+		return nil
+	}
+	return c.Req.Context().Value(key)
+}
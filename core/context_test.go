@@ -0,0 +1,391 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newMultipartRequest 构造一个包含普通字段与多个文件的multipart请求
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string][]string) *http.Request {
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for key, value := range fields {
+		if err := mw.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, contents := range files {
+		for i, content := range contents {
+			w, err := mw.CreateFormFile(key, key+string(rune('a'+i))+".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = w.Write([]byte(content))
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// TestContextMultipartForm 测试延迟解析multipart表单
+func TestContextMultipartForm(t *testing.T) {
+	req := newMultipartRequest(t, map[string]string{"name": "vgo"}, map[string][]string{
+		"file":  {"first"},
+		"files": {"one", "two"},
+	})
+	c := NewContext(httptest.NewRecorder(), req)
+
+	fh, err := c.FormFile("file")
+	if err != nil || fh.Filename != "filea.txt" {
+		t.Fatalf("FormFile 应该能够直接读取上传文件: %v", err)
+	}
+	fhs, err := c.FormFiles("files")
+	if err != nil || len(fhs) != 2 {
+		t.Fatalf("FormFiles 应该返回2个文件: %v", err)
+	}
+	form, err := c.MultipartForm()
+	if err != nil || form.Value["name"][0] != "vgo" {
+		t.Fatalf("MultipartForm 解析结果不正确: %v", err)
+	}
+	if _, err = c.FormFile("missing"); err != http.ErrMissingFile {
+		t.Fatalf("文件不存在时应该返回 http.ErrMissingFile, 实际为 %v", err)
+	}
+
+	c = NewContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/upload", nil))
+	if _, err = c.FormFile("file"); err == nil || err.Error() == "" {
+		t.Fatal("非multipart请求应该返回可读的错误")
+	}
+}
+
+// TestContextSaveUploadedFile 测试保存上传文件时创建目录与大小限制
+func TestContextSaveUploadedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vgo-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := New()
+	r.MaxUploadFileSize = 4
+	r.POST("/upload", func(c *Context) {
+		fhs, err := c.FormFiles("files")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fh := range fhs {
+			dst := filepath.Join(dir, "nested", "path", fh.Filename)
+			if err = c.SaveUploadedFile(fh, dst); err != nil {
+				c.String(http.StatusRequestEntityTooLarge, "%s", err)
+				return
+			}
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, nil, map[string][]string{"files": {"one", "two"}}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("上传应该成功: %d %s", w.Code, w.Body.String())
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "nested", "path", "filesb.txt")); err != nil || string(data) != "two" {
+		t.Fatalf("保存的文件内容不正确: %q %v", data, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newMultipartRequest(t, nil, map[string][]string{"files": {"too large"}}))
+	if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != ErrFileTooLarge.Error() {
+		t.Fatalf("超过大小限制时应该返回 ErrFileTooLarge: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestContextMultipartCleanup 测试请求结束后清理临时文件
+func TestContextMultipartCleanup(t *testing.T) {
+	r := New()
+	r.MaxMultipartMemory = 1
+	var tmpFile string
+	r.POST("/upload", func(c *Context) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if osFile, ok := f.(*os.File); ok {
+			tmpFile = osFile.Name()
+		}
+		_ = f.Close()
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), newMultipartRequest(t, nil, map[string][]string{"file": {"written to disk"}}))
+	if tmpFile == "" {
+		t.Fatal("超过 MaxMultipartMemory 的文件应该写入临时文件")
+	}
+	if _, err := os.Stat(tmpFile); !os.IsNotExist(err) {
+		t.Fatalf("请求结束后临时文件应该被删除: %v", err)
+	}
+}
+
+// TestContextQuery 测试url查询参数的缓存访问
+func TestContextQuery(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?name=Manu&lastname=&ids[a]=1&ids[b]=2&tags=go&tags=web", nil)
+	c := NewContext(httptest.NewRecorder(), req)
+
+	if c.Query("name") != "Manu" || c.Query("wtf") != "" {
+		t.Fatal("Query 结果不正确")
+	}
+	if c.DefaultQuery("lastname", "none") != "" || c.DefaultQuery("id", "none") != "none" {
+		t.Fatal("DefaultQuery 结果不正确")
+	}
+	if value, ok := c.GetQuery("lastname"); value != "" || !ok {
+		t.Fatal("GetQuery 对于空值应该返回 (\"\", true)")
+	}
+	if !reflect.DeepEqual(c.QueryArray("tags"), []string{"go", "web"}) {
+		t.Fatalf("QueryArray 结果不正确: %v", c.QueryArray("tags"))
+	}
+	if !reflect.DeepEqual(c.QueryMap("ids"), map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("QueryMap 结果不正确: %v", c.QueryMap("ids"))
+	}
+	if _, ok := c.GetQueryMap("tags"); ok {
+		t.Fatal("GetQueryMap 对于非map参数应该返回false")
+	}
+
+	// 查询参数只解析一次
+	c.Req.URL.RawQuery = "name=changed"
+	if c.Query("name") != "Manu" {
+		t.Fatal("Query 应该使用缓存的解析结果")
+	}
+}
+
+// TestContextPostForm 测试表单参数的缓存访问，且不混入url查询参数
+func TestContextPostForm(t *testing.T) {
+	body := strings.NewReader("name=Manu&empty=&ids[a]=1&ids[b]=2&tags=go&tags=web")
+	req := httptest.NewRequest("POST", "/?query=1&name=query", body)
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	c := NewContext(httptest.NewRecorder(), req)
+
+	if c.PostForm("name") != "Manu" || c.PostForm("query") != "" {
+		t.Fatal("PostForm 不应该读取url查询参数")
+	}
+	if c.DefaultPostForm("empty", "none") != "" || c.DefaultPostForm("missing", "none") != "none" {
+		t.Fatal("DefaultPostForm 结果不正确")
+	}
+	if !reflect.DeepEqual(c.PostFormArray("tags"), []string{"go", "web"}) {
+		t.Fatalf("PostFormArray 结果不正确: %v", c.PostFormArray("tags"))
+	}
+	if !reflect.DeepEqual(c.PostFormMap("ids"), map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("PostFormMap 结果不正确: %v", c.PostFormMap("ids"))
+	}
+
+	req = newMultipartRequest(t, map[string]string{"name": "multipart", "ids[x]": "9"}, nil)
+	c = NewContext(httptest.NewRecorder(), req)
+	if c.PostForm("name") != "multipart" || c.PostFormMap("ids")["x"] != "9" {
+		t.Fatal("PostForm 应该能够读取multipart表单字段")
+	}
+}
+
+// TestContextWithFallback 测试Context作为context.Context使用时，取消信号总是来自请求的context，
+// Value 只在开启 ContextWithFallback 时回退到请求的context
+func TestContextWithFallback(t *testing.T) {
+	type ctxKey struct{}
+	reqCtx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	req := httptest.NewRequest("GET", "/", nil).WithContext(reqCtx)
+
+	c := NewContext(httptest.NewRecorder(), req)
+	c.engine = New()
+	c.Set("user", "vgo")
+	if c.Value(ctxKey{}) != nil {
+		t.Fatal("未开启 ContextWithFallback 时 Value 不应该回退到请求的context")
+	}
+	if c.Value("user") != "vgo" || c.Value(ContextKey) != c {
+		t.Fatal("Value 应该返回通过Set设置的值")
+	}
+	if _, ok := c.Deadline(); ok {
+		t.Fatal("请求的context没有截止时间")
+	}
+
+	done := make(chan error)
+	go func(ctx context.Context) {
+		<-ctx.Done()
+		done <- ctx.Err()
+	}(c)
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("取消后 Err 应该为 context.Canceled, 实际为 %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("请求取消后 Done 应该被关闭")
+	}
+
+	c.engine.ContextWithFallback = true
+	if c.Value(ctxKey{}) != "request" {
+		t.Fatal("Value 应该回退到请求的context")
+	}
+
+	c.Req = nil
+	if c.Done() != nil || c.Err() != nil || c.Value(ctxKey{}) != nil {
+		t.Fatal("没有请求时不应该回退到请求的context")
+	}
+}
+
+// TestContextCopy 测试复制出的Context与原Context互不影响
+func TestContextCopy(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/hello/vgo", nil))
+	c.Params = map[string]string{"name": "vgo"}
+	c.Set("user", "pjx")
+
+	cp := c.Copy()
+	cp.Params["name"] = "changed"
+	cp.Set("user", "changed")
+	if c.Param("name") != "vgo" || c.GetString("user") != "pjx" {
+		t.Fatal("修改副本不应该影响原Context")
+	}
+
+	c.reset()
+	if cp.Req == nil || cp.Path != "/hello/vgo" || cp.Param("name") != "changed" || cp.GetString("user") != "changed" {
+		t.Fatal("原Context重置后副本应该依然有效")
+	}
+	if !cp.IsAborted() {
+		t.Fatal("副本不应该继续执行handler链")
+	}
+	if _, err := cp.Writer.Write([]byte("late")); err != ErrResponseDetached {
+		t.Fatalf("向副本写入响应应该返回 ErrResponseDetached, 实际为 %v", err)
+	}
+	cp.String(http.StatusOK, "late write should not panic")
+}
+
+// TestContextCopyInGoroutine 在后台goroutine中使用副本，同时原Context被放回对象池并复用，
+// 需要配合 go test -race 运行
+func TestContextCopyInGoroutine(t *testing.T) {
+	r := New()
+	results := make(chan string, 100)
+	r.GET("/user/:name", func(c *Context) {
+		c.Set("trace", c.Param("name"))
+		cp := c.Copy()
+		go func() {
+			cp.Set("audit", true)
+			cp.String(http.StatusOK, "ignored")
+			results <- cp.Param("name") + "-" + cp.GetString("trace") + "-" + cp.Query("q")
+		}()
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i := 0; i < 100; i++ {
+		name := string(rune('a' + i%26))
+		performRequest(r, "GET", "/user/"+name+"?q="+name)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		result := <-results
+		parts := strings.Split(result, "-")
+		if len(parts) != 3 || parts[0] != parts[1] || parts[1] != parts[2] {
+			t.Fatalf("副本中的数据被其他请求覆盖: %q", result)
+		}
+		seen[parts[0]] = true
+	}
+	if len(seen) != 26 {
+		t.Fatalf("应该收到26个不同的请求参数, 实际为 %d", len(seen))
+	}
+}
+
+// TestContextRequestHelpers 测试请求头相关的辅助方法
+func TestContextRequestHelpers(t *testing.T) {
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "WebSocket")
+	c := NewContext(httptest.NewRecorder(), req)
+
+	if c.GetHeader("Upgrade") != "WebSocket" {
+		t.Fatal("GetHeader 结果不正确")
+	}
+	if c.ContentType() != MIMEJSON {
+		t.Fatalf("ContentType 应该去掉参数部分, 实际为 %q", c.ContentType())
+	}
+	if !c.IsWebsocket() {
+		t.Fatal("IsWebsocket 应该为true")
+	}
+	req.Header.Set("Connection", "keep-alive")
+	if c.IsWebsocket() {
+		t.Fatal("Connection 不包含 upgrade 时 IsWebsocket 应该为false")
+	}
+}
+
+// TestContextFullPath 测试获取匹配到的路由
+func TestContextFullPath(t *testing.T) {
+	r := New()
+	var fullPath string
+	r.GET("/hello/:name", func(c *Context) {
+		fullPath = c.FullPath()
+	})
+	performRequest(r, "GET", "/hello/vgo")
+	if fullPath != "/hello/:name" {
+		t.Fatalf("FullPath 应该为 /hello/:name, 实际为 %q", fullPath)
+	}
+
+	r.Use(func(c *Context) {
+		fullPath = c.FullPath()
+		c.Next()
+	})
+	performRequest(r, "GET", "/not/found")
+	if fullPath != "" {
+		t.Fatalf("未匹配到路由时 FullPath 应该为空, 实际为 %q", fullPath)
+	}
+}
+
+// TestContextGetRawData 测试请求体只读取一次并缓存
+func TestContextGetRawData(t *testing.T) {
+	r := New()
+	r.MaxRawDataSize = 8
+	r.Use(func(c *Context) {
+		if _, err := c.GetRawData(); err != nil {
+			c.Abort()
+			c.String(http.StatusRequestEntityTooLarge, "%s", err)
+			return
+		}
+		c.Next()
+	})
+	r.POST("/raw", func(c *Context) {
+		data, _ := c.GetRawData()
+		body, _ := ioutil.ReadAll(c.Req.Body)
+		c.String(http.StatusOK, "%s|%s", data, body)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/raw", strings.NewReader("vgo body")))
+	if w.Body.String() != "vgo body|vgo body" {
+		t.Fatalf("中间件读取请求体后handler应该依然能够读取: %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/raw", strings.NewReader("body too large")))
+	if w.Code != http.StatusRequestEntityTooLarge || w.Body.String() != ErrBodyTooLarge.Error() {
+		t.Fatalf("超过大小限制时应该返回 ErrBodyTooLarge: %d %q", w.Code, w.Body.String())
+	}
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/raw", strings.NewReader("body too large")))
+	c.engine = r
+	if _, err := c.GetRawData(); err != ErrBodyTooLarge {
+		t.Fatalf("超过大小限制时应该返回 ErrBodyTooLarge: %v", err)
+	}
+	if body, _ := ioutil.ReadAll(c.Req.Body); string(body) != "body too large" {
+		t.Fatalf("超过大小限制后请求体应该依然完整: %q", body)
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestContextSetCookie 测试cookie读写与SameSite属性
+func TestContextSetCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie("user", "vgo 框架", 1, "/", "localhost", true, true)
+	want := "user=vgo+%E6%A1%86%E6%9E%B6; Path=/; Domain=localhost; Max-Age=1; HttpOnly; Secure; SameSite=Lax"
+	if got := w.Header().Get("Set-Cookie"); got != want {
+		t.Fatalf("Set-Cookie 应该为 %q, 实际为 %q", want, got)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Cookie", "user=vgo+%E6%A1%86%E6%9E%B6")
+	c = NewContext(httptest.NewRecorder(), req)
+	if value, err := c.Cookie("user"); err != nil || value != "vgo 框架" {
+		t.Fatalf("Cookie 结果不正确: %q %v", value, err)
+	}
+	if _, err := c.Cookie("missing"); err != http.ErrNoCookie {
+		t.Fatalf("cookie不存在时应该返回 http.ErrNoCookie, 实际为 %v", err)
+	}
+}
+
+// TestSecureCookie 测试加密cookie的防篡改、密钥轮换与过期
+func TestSecureCookie(t *testing.T) {
+	oldCodec := NewSecureCookie([]byte("old-key"))
+	codec := NewSecureCookie([]byte("new-key"), []byte("old-key"))
+
+	encoded, err := oldCodec.Encode("session", "pjx@cq.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := codec.Decode("session", encoded, 0); err != nil || value != "pjx@cq.com" {
+		t.Fatalf("轮换后旧密钥签发的cookie应该依然有效: %q %v", value, err)
+	}
+	if _, err = codec.Decode("other", encoded, 0); err != ErrInvalidCookie {
+		t.Fatal("cookie的值不能被挪用到其他名称的cookie")
+	}
+	tampered := []byte(encoded)
+	tampered[len(tampered)-1] ^= 1
+	if _, err = codec.Decode("session", string(tampered), 0); err != ErrInvalidCookie {
+		t.Fatal("被篡改的cookie应该返回 ErrInvalidCookie")
+	}
+
+	encoded, _ = codec.Encode("session", "pjx@cq.com")
+	if _, err = oldCodec.Decode("session", encoded, 0); err != ErrInvalidCookie {
+		t.Fatal("新密钥签发的cookie不应该被只有旧密钥的编解码器解密")
+	}
+	codec.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err = codec.Decode("session", encoded, 3600); err != ErrInvalidCookie {
+		t.Fatal("过期的cookie应该返回 ErrInvalidCookie")
+	}
+}
+
+// TestContextSecureCookie 测试通过Context读写加密cookie
+func TestContextSecureCookie(t *testing.T) {
+	r := New()
+	r.GET("/login", func(c *Context) {
+		if err := c.SetSecureCookie("session", "pjx@cq.com", 3600, "/", "", false, true); err != nil {
+			t.Fatal(err)
+		}
+	})
+	r.GET("/me", func(c *Context) {
+		user, err := c.SecureCookie("session", 3600)
+		if err != nil {
+			c.String(http.StatusUnauthorized, "%s", err)
+			return
+		}
+		c.String(http.StatusOK, "%s", user)
+	})
+
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := c.SetSecureCookie("session", "x", 0, "", "", false, false); err != ErrNoCookieKeys {
+		t.Fatalf("未设置密钥时应该返回 ErrNoCookieKeys, 实际为 %v", err)
+	}
+
+	r.SetCookieKeys([]byte("secret"))
+	w := performRequest(r, "GET", "/login")
+	cookie := w.Result().Cookies()[0]
+	if cookie.Value == "" || cookie.Value == "pjx@cq.com" {
+		t.Fatalf("加密cookie不应该包含明文: %q", cookie.Value)
+	}
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "pjx@cq.com" {
+		t.Fatalf("加密cookie解密结果不正确: %d %q", w.Code, w.Body.String())
+	}
+}
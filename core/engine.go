@@ -1,11 +1,18 @@
 package core
 
 import (
+	"fmt"
+	"html/template"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"vgo/core/websocket"
 	"vgo/log"
 )
 
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
 // HandlerFunc 定义vgo对于请求的handler
 type HandlerFunc func(ctx *Context)
 
@@ -14,29 +21,114 @@ type Engine struct {
 	*GroupRouter // Engine继承了GroupRouter的所有属性和方法，所以*(Engine).engine是指向自己的，将Engine作为最顶层的分组，也就是说Engine拥有Router的所有能力
 	router       *Router
 	groups       []*GroupRouter
+
+	// MaxMultipartMemory 解析multipart表单时最多使用的内存，超出部分写入临时文件，默认32MB
+	MaxMultipartMemory int64
+
+	// MaxUploadFileSize 单个上传文件保存时允许的最大字节数，0表示不限制
+	MaxUploadFileSize int64
+
+	// MaxRawDataSize Context.GetRawData 允许读取的最大请求体字节数，0表示不限制
+	MaxRawDataSize int64
+
+	// ContextWithFallback 为true时，Context.Value 在Set设置的键中找不到时会回退到 Context.Req.Context()。
+	// Deadline、Done 与 Err 总是使用请求的context，客户端断开连接时取消信号会传递给下游调用
+	ContextWithFallback bool
+
+	// RemoteIPHeaders 请求来自可信代理时，Context.ClientIP 依次从这些请求头中解析客户端IP，
+	// 默认为 X-Forwarded-For 与 X-Real-IP，使用Cloudflare时可以加入 CF-Connecting-IP
+	RemoteIPHeaders []string
+
+	// RedirectAllowedHosts Context.SafeRedirect 允许跳转的外部host白名单，相对地址与当前请求的host总是允许跳转
+	RedirectAllowedHosts []string
+
+	// HTMLDebug 为true时每次渲染HTML都会重新解析模板文件，修改模板后无需重启服务，仅建议在开发环境开启
+	HTMLDebug bool
+
+	// WebSocket Context.Upgrade 使用的升级配置，可以设置单条消息的读取上限、Origin校验、子协议与压缩
+	WebSocket websocket.Upgrader
+
+	bare         bool          // 不注册默认中间件，通过 Bare 设置
+	recovery     HandlerFunc   // 错误恢复中间件，通过 WithRecovery 设置
+	secureCookie *SecureCookie // 加密cookie编解码器
+	trustedCIDRs []*net.IPNet  // 可信代理网段，通过 SetTrustedProxies 设置
+	pool         sync.Pool     // Context对象池，避免每次请求都创建新的Context
+
+	delims     Delims                        // 模板分隔符
+	funcMap    template.FuncMap              // 模板函数
+	html       htmlTemplates                 // 已加载的模板
+	htmlLoader func() (htmlTemplates, error) // 模板加载函数，HTMLDebug模式下用于重新解析模板
+}
+
+// Option 引擎的配置函数，在 New 中按顺序执行
+type Option func(*Engine)
+
+// Bare 创建不带任何默认中间件的引擎，默认的 Recovery 不会被注册，可以自行通过 Use 注册需要的中间件
+func Bare() Option {
+	return func(engine *Engine) {
+		engine.bare = true
+	}
+}
+
+// WithRecovery 使用自定义的错误恢复中间件替换默认的 Recovery，例如 CustomRecovery
+func WithRecovery(recovery HandlerFunc) Option {
+	return func(engine *Engine) {
+		engine.recovery = recovery
+	}
 }
 
-// New 引擎的构造方法
-func New() (engine *Engine) {
-	engine = &Engine{router: newRouter()}
+// New 引擎的构造方法，默认注册 Recovery 中间件
+func New(opts ...Option) (engine *Engine) {
+	engine = &Engine{
+		router:             newRouter(),
+		MaxMultipartMemory: defaultMultipartMemory,
+		RemoteIPHeaders:    []string{"X-Forwarded-For", "X-Real-IP"},
+		delims:             Delims{Left: "{{", Right: "}}"},
+		funcMap:            template.FuncMap{},
+	}
 	engine.GroupRouter = &GroupRouter{engine: engine}
-	// 初始化插入错误恢复中间件 TODO 优化
-	engine.GroupRouter.middlewares = append(engine.GroupRouter.middlewares, Recovery())
+	for _, opt := range opts {
+		opt(engine)
+	}
+	// 非Bare模式下错误恢复中间件总是第一个执行，保证后续中间件的panic也能被捕获
+	if !engine.bare {
+		if engine.recovery == nil {
+			engine.recovery = Recovery()
+		}
+		engine.GroupRouter.middlewares = append([]HandlerFunc{engine.recovery}, engine.GroupRouter.middlewares...)
+	}
 	engine.groups = []*GroupRouter{engine.GroupRouter}
+	engine.pool.New = func() interface{} {
+		return engine.allocateContext()
+	}
 	return
 }
 
+// allocateContext 创建一个绑定到当前引擎的空Context
+func (engine *Engine) allocateContext() *Context {
+	return &Context{engine: engine, index: -1}
+}
+
 // addRoute 路由添加方法，调用router模块的方法
-func (engine *Engine) addRoute(method string, pattern string, handler HandlerFunc) {
-	engine.router.addRoute(method, pattern, handler)
+func (engine *Engine) addRoute(method string, pattern string, handler HandlerFunc) *RouteInfo {
+	return engine.router.addRoute(method, pattern, handler)
 }
 
-func (engine *Engine) GET(pattern string, handler HandlerFunc) {
-	engine.addRoute("GET", pattern, handler)
+func (engine *Engine) GET(pattern string, handler HandlerFunc) *RouteInfo {
+	return engine.addRoute("GET", pattern, handler)
 }
 
-func (engine *Engine) POST(pattern string, handler HandlerFunc) {
-	engine.addRoute("POST", pattern, handler)
+func (engine *Engine) POST(pattern string, handler HandlerFunc) *RouteInfo {
+	return engine.addRoute("POST", pattern, handler)
+}
+
+// URL 根据路由名称与参数生成请求路径
+func (engine *Engine) URL(name string, params map[string]string) (string, error) {
+	ri, ok := engine.router.getNamedRoute(name)
+	if !ok {
+		return "", fmt.Errorf("route %q is not defined", name)
+	}
+	return ri.URL(params)
 }
 
 // Run 定义启动http server的方法
@@ -45,18 +137,37 @@ func (engine *Engine) Run(addr string) (err error) {
 	return http.ListenAndServe(addr, engine)
 }
 
-func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+// groupMiddlewares 收集请求路径所属分组的中间件
+func (engine *Engine) groupMiddlewares(path string) []HandlerFunc {
 	var middlewares []HandlerFunc
 	for _, group := range engine.groups {
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
+		if strings.HasPrefix(path, group.prefix) {
 			middlewares = append(middlewares, group.middlewares...)
 		}
 	}
-	// 1. 每一次请求都会生成新的context TODO 为请求做缓存
-	c := NewContext(w, req)
+	return middlewares
+}
+
+func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	middlewares := engine.groupMiddlewares(req.URL.Path)
+	// 1. 从对象池中取出context，请求结束后放回池中复用
+	c := engine.pool.Get().(*Context)
+	c.writermem.reset(w)
+	c.Writer = &c.writermem
+	c.Req = req
+	c.Path = req.URL.Path
+	c.Method = req.Method
 
 	c.Handlers = middlewares
 
 	// 2. 交由router的handle函数处理请求
 	engine.router.handle(c)
+	// 只设置了状态码而没有写入响应体时，也需要把响应头发送出去
+	c.Writer.WriteHeaderNow()
+
+	// 3. 请求处理结束后清理上传产生的临时文件
+	c.removeMultipartFiles()
+
+	c.reset()
+	engine.pool.Put(c)
 }
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrorCode is a stable business error code declared by a service, e.g.
+//
+//	var UserNotFound = core.RegisterErrorCode("USER_NOT_FOUND", http.StatusNotFound, "user %s not found", "user.not_found")
+type ErrorCode struct {
+	// Code 错误码，在整个服务中唯一
+	Code string `json:"code"`
+	// Status 返回给客户端的HTTP状态码
+	Status int `json:"status"`
+	// Message fmt风格的消息模板，参数为 NewError 的 args
+	Message string `json:"message"`
+	// I18nKey 客户端或网关做多语言翻译时使用的key
+	I18nKey string `json:"i18n_key,omitempty"`
+}
+
+// CodedError is the error wrapped by the *Error values built from an ErrorCode.
+// It can be matched with errors.As, ErrorHandler uses it to pick the status code.
+type CodedError struct {
+	Code *ErrorCode
+	Args []interface{}
+}
+
+// Error implements the error interface with the formatted message template.
+func (e *CodedError) Error() string {
+	if len(e.Args) == 0 {
+		return e.Code.Message
+	}
+	return fmt.Sprintf(e.Code.Message, e.Args...)
+}
+
+// errorCodes 已注册的错误码
+var errorCodes = struct {
+	sync.RWMutex
+	m map[string]*ErrorCode
+}{m: make(map[string]*ErrorCode)}
+
+// RegisterErrorCode declares an error code. It panics if the code is empty or already registered,
+// so codes should be declared in package level variables or init functions.
+func RegisterErrorCode(code string, status int, message, i18nKey string) *ErrorCode {
+	if code == "" {
+		panic("vgo: error code must not be empty")
+	}
+	errorCodes.Lock()
+	defer errorCodes.Unlock()
+	if _, ok := errorCodes.m[code]; ok {
+		panic("vgo: error code " + code + " already registered")
+	}
+	ec := &ErrorCode{Code: code, Status: status, Message: message, I18nKey: i18nKey}
+	errorCodes.m[code] = ec
+	return ec
+}
+
+// LookupErrorCode returns the registered error code.
+func LookupErrorCode(code string) (*ErrorCode, bool) {
+	errorCodes.RLock()
+	defer errorCodes.RUnlock()
+	ec, ok := errorCodes.m[code]
+	return ec, ok
+}
+
+// ErrorCodes returns all registered error codes sorted by code, e.g. to generate documentation.
+func ErrorCodes() []ErrorCode {
+	errorCodes.RLock()
+	codes := make([]ErrorCode, 0, len(errorCodes.m))
+	for _, ec := range errorCodes.m {
+		codes = append(codes, *ec)
+	}
+	errorCodes.RUnlock()
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// NewError builds a public *Error for a registered code, args fill the message template.
+// The meta data holds the code, the i18n key and the args, so the error is rendered as
+//
+//	{"code": "USER_NOT_FOUND", "error": "user 7 not found", "i18n_key": "user.not_found", "args": ["7"]}
+//
+// An unknown code yields a private error, which is never shown to the client.
+func NewError(code string, args ...interface{}) *Error {
+	ec, ok := LookupErrorCode(code)
+	if !ok {
+		return &Error{
+			Err:  fmt.Errorf("vgo: unknown error code %s", code),
+			Type: ErrorTypePrivate,
+		}
+	}
+	return ec.New(args...)
+}
+
+// New builds a public *Error for the code, see NewError.
+func (ec *ErrorCode) New(args ...interface{}) *Error {
+	meta := H{"code": ec.Code}
+	if ec.I18nKey != "" {
+		meta["i18n_key"] = ec.I18nKey
+	}
+	if len(args) > 0 {
+		meta["args"] = args
+	}
+	return &Error{
+		Err:  &CodedError{Code: ec, Args: args},
+		Type: ErrorTypePublic,
+		Meta: meta,
+	}
+}
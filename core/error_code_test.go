@@ -0,0 +1,75 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+var (
+	testUserNotFound = RegisterErrorCode("TEST_USER_NOT_FOUND", http.StatusNotFound, "user %s not found", "user.not_found")
+	testRateLimited  = RegisterErrorCode("TEST_RATE_LIMITED", http.StatusTooManyRequests, "too many requests", "")
+)
+
+// TestNewError 测试通过错误码构造错误以及JSON渲染
+func TestNewError(t *testing.T) {
+	err := NewError("TEST_USER_NOT_FOUND", "7")
+	if err.Error() != "user 7 not found" || !err.IsType(ErrorTypePublic) {
+		t.Fatalf("错误信息不正确: %q", err.Error())
+	}
+	var coded *CodedError
+	if !errors.As(err, &coded) || coded.Code != testUserNotFound {
+		t.Fatal("应该可以通过 errors.As 取得错误码")
+	}
+	data, _ := json.Marshal(err)
+	if string(data) != `{"args":["7"],"code":"TEST_USER_NOT_FOUND","error":"user 7 not found","i18n_key":"user.not_found"}` {
+		t.Fatalf("JSON 不正确: %s", data)
+	}
+	data, _ = json.Marshal(testRateLimited.New())
+	if string(data) != `{"code":"TEST_RATE_LIMITED","error":"too many requests"}` {
+		t.Fatalf("JSON 不正确: %s", data)
+	}
+
+	if unknown := NewError("TEST_NO_SUCH_CODE"); !unknown.IsType(ErrorTypePrivate) {
+		t.Fatal("未注册的错误码应该是私有错误")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("重复注册错误码应该panic")
+		}
+	}()
+	RegisterErrorCode("TEST_RATE_LIMITED", http.StatusTooManyRequests, "", "")
+}
+
+// TestErrorCodes 测试列出已注册的错误码
+func TestErrorCodes(t *testing.T) {
+	var found []string
+	for _, ec := range ErrorCodes() {
+		if ec.Code == "TEST_RATE_LIMITED" || ec.Code == "TEST_USER_NOT_FOUND" {
+			found = append(found, ec.Code)
+		}
+	}
+	if len(found) != 2 || found[0] != "TEST_RATE_LIMITED" {
+		t.Fatalf("错误码列表应该按code排序: %v", found)
+	}
+	if ec, ok := LookupErrorCode("TEST_USER_NOT_FOUND"); !ok || ec.Status != http.StatusNotFound {
+		t.Fatal("应该可以查找已注册的错误码")
+	}
+}
+
+// TestErrorHandlerErrorCode 测试错误处理中间件使用错误码的状态码
+func TestErrorHandlerErrorCode(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/user/:id", func(c *Context) {
+		c.Error(NewError("TEST_USER_NOT_FOUND", c.Param("id")))
+	})
+	w := performRequest(r, "GET", "/user/7")
+	var problem Problem
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	if w.Code != http.StatusNotFound || problem.Code != "TEST_USER_NOT_FOUND" || problem.Detail != "user 7 not found" {
+		t.Fatalf("错误码的状态码不正确: %d %s", w.Code, w.Body.String())
+	}
+}
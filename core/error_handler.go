@@ -0,0 +1,170 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"vgo/log"
+)
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details.
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem is the RFC 7807 problem details object rendered by ErrorHandler.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code is the registered error code of the error that determined the status, see NewError.
+	Code string `json:"code,omitempty"`
+
+	// Errors lists the public errors of the request, see errorMsgs.JSON.
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+// ErrorMapping maps the errors matched by Match to an HTTP status code.
+type ErrorMapping struct {
+	Match  func(err error) bool
+	Status int
+}
+
+// ErrorAs maps every error that errors.As can convert to T to status, e.g.
+//
+//	core.ErrorAs[*json.SyntaxError](http.StatusBadRequest)
+func ErrorAs[T error](status int) ErrorMapping {
+	return ErrorMapping{
+		Match: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+		Status: status,
+	}
+}
+
+// ErrorIs maps every error for which errors.Is(err, target) holds to status, e.g.
+//
+//	core.ErrorIs(sql.ErrNoRows, http.StatusNotFound)
+func ErrorIs(target error, status int) ErrorMapping {
+	return ErrorMapping{
+		Match: func(err error) bool {
+			return errors.Is(err, target)
+		},
+		Status: status,
+	}
+}
+
+// ErrorHandlerConfig 错误处理中间件的配置
+type ErrorHandlerConfig struct {
+	// Mappings 按顺序匹配错误链，第一个匹配的映射决定状态码
+	Mappings []ErrorMapping
+
+	// TypeStatus ErrorType 对应的状态码，没有映射匹配且handler没有设置错误状态码时使用，
+	// 默认 ErrorTypeBind 为400，ErrorTypeRender 为500
+	TypeStatus map[ErrorType]int
+
+	// ProblemType 返回 problem 的 type 字段，默认为 "about:blank"
+	ProblemType func(status int, err *Error) string
+}
+
+// defaultTypeStatus TypeStatus 的默认值
+var defaultTypeStatus = map[ErrorType]int{
+	ErrorTypeBind:   http.StatusBadRequest,
+	ErrorTypeRender: http.StatusInternalServerError,
+}
+
+// ErrorHandler returns a middleware that turns the errors collected in c.Errors into an
+// RFC 7807 application/problem+json response, so handlers can simply call c.Error(err) and return.
+// See ErrorHandlerWithConfig for how the status code is chosen.
+func ErrorHandler(mappings ...ErrorMapping) HandlerFunc {
+	return ErrorHandlerWithConfig(ErrorHandlerConfig{Mappings: mappings})
+}
+
+// ErrorHandlerWithConfig returns an error handling middleware with the given config.
+//
+// The status code is taken from the last error, in this order: the first matching
+// ErrorMapping, the status of its registered ErrorCode (see NewError), the error status
+// already set by the handler (e.g. with AbortWithError), the TypeStatus of the error type
+// and finally 500.
+// Only the messages of ErrorTypePublic errors are rendered in the detail, every other error is
+// logged through vgo/log and never sent to the client. If the handler already wrote the
+// response, errors are only logged.
+func ErrorHandlerWithConfig(config ErrorHandlerConfig) HandlerFunc {
+	if config.TypeStatus == nil {
+		config.TypeStatus = defaultTypeStatus
+	}
+	return func(c *Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		for _, err := range c.Errors {
+			if !err.IsType(ErrorTypePublic) {
+				log.Error(c.Method + " " + c.Path + ": " + err.Error())
+			}
+		}
+		if c.Writer.Written() {
+			return
+		}
+
+		last := c.Errors.Last()
+		status := config.status(c, last)
+		problem := Problem{
+			Type:     "about:blank",
+			Title:    http.StatusText(status),
+			Status:   status,
+			Instance: c.Req.URL.Path,
+		}
+		var coded *CodedError
+		if errors.As(last.Err, &coded) {
+			problem.Code = coded.Code.Code
+		}
+		if config.ProblemType != nil {
+			problem.Type = config.ProblemType(status, last)
+		}
+		if public := c.Errors.ByType(ErrorTypePublic); len(public) > 0 {
+			problem.Detail = strings.Join(public.Errors(), "; ")
+			problem.Errors = public.JSON()
+		}
+
+		c.Abort()
+		c.SetHeader("Content-Type", MIMEProblemJSON)
+		c.Status(status)
+		if err := json.NewEncoder(c.Writer).Encode(problem); err != nil {
+			log.Error("render problem: " + err.Error())
+		}
+	}
+}
+
+// status 计算错误对应的状态码
+func (config *ErrorHandlerConfig) status(c *Context, err *Error) int {
+	for _, mapping := range config.Mappings {
+		if mapping.Match(err.Err) {
+			return mapping.Status
+		}
+	}
+	var coded *CodedError
+	if errors.As(err.Err, &coded) && coded.Code.Status != 0 {
+		return coded.Code.Status
+	}
+	if c.StatusCode >= http.StatusBadRequest {
+		return c.StatusCode
+	}
+	// 按类型从高位到低位匹配，保证同时属于多个类型时结果稳定
+	types := make([]ErrorType, 0, len(config.TypeStatus))
+	for typ := range config.TypeStatus {
+		types = append(types, typ)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] > types[j] })
+	for _, typ := range types {
+		if err.IsType(typ) {
+			return config.TypeStatus[typ]
+		}
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"vgo/log"
+)
+
+type notFoundError struct{ id string }
+
+func (e *notFoundError) Error() string { return "user " + e.id + " not found" }
+
+var errQuota = errors.New("quota exceeded")
+
+// TestErrorHandler 测试错误映射为状态码并渲染 problem+json，私有错误只记录日志
+func TestErrorHandler(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetLogPath(filepath.Join(t.TempDir(), "log.txt"))
+	defer func() {
+		log.SetOutput(os.Stdout)
+		log.SetLogPath(log.DefaultLogPath)
+	}()
+
+	r := New()
+	r.Use(ErrorHandler(ErrorAs[*notFoundError](http.StatusNotFound), ErrorIs(errQuota, http.StatusTooManyRequests)))
+	r.GET("/user/:id", func(c *Context) {
+		c.Error(&notFoundError{id: c.Param("id")}).SetType(ErrorTypePublic)
+	})
+	r.GET("/quota", func(c *Context) {
+		c.Error(errQuota)
+	})
+	r.GET("/db", func(c *Context) {
+		c.Error(errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	})
+	r.GET("/bind", func(c *Context) {
+		c.Error(errors.New("name is required")).SetType(ErrorTypeBind | ErrorTypePublic)
+	})
+	r.GET("/auth", func(c *Context) {
+		c.AbortWithError(http.StatusUnauthorized, errors.New("token expired")).SetType(ErrorTypePublic)
+	})
+	r.GET("/written", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+		c.Error(errors.New("after write"))
+	})
+
+	cases := []struct {
+		path   string
+		status int
+		detail string
+	}{
+		{"/user/7", http.StatusNotFound, "user 7 not found"},
+		{"/quota", http.StatusTooManyRequests, ""},
+		{"/db", http.StatusInternalServerError, ""},
+		{"/bind", http.StatusBadRequest, "name is required"},
+		{"/auth", http.StatusUnauthorized, "token expired"},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, "GET", tc.path)
+		var problem Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s 响应不是合法的JSON: %s", tc.path, w.Body.String())
+		}
+		if w.Code != tc.status || w.Header().Get("Content-Type") != MIMEProblemJSON {
+			t.Fatalf("%s 状态码或类型不正确: %d %s", tc.path, w.Code, w.Header().Get("Content-Type"))
+		}
+		if problem.Type != "about:blank" || problem.Status != tc.status || problem.Title != http.StatusText(tc.status) ||
+			problem.Detail != tc.detail || problem.Instance != tc.path {
+			t.Fatalf("%s problem 不正确: %+v", tc.path, problem)
+		}
+		if strings.Contains(w.Body.String(), "10.0.0.1") || strings.Contains(w.Body.String(), "quota exceeded") {
+			t.Fatalf("私有错误不应该返回给客户端: %s", w.Body.String())
+		}
+	}
+
+	if !strings.Contains(out.String(), "GET /db: dial tcp 10.0.0.1:3306") || strings.Contains(out.String(), "token expired") {
+		t.Fatalf("私有错误应该记录日志，公开错误不需要: %s", out.String())
+	}
+
+	w := performRequest(r, "GET", "/written")
+	if w.Code != http.StatusOK || w.Body.String() != "ok" || !strings.Contains(out.String(), "after write") {
+		t.Fatalf("已经写入响应时只记录日志: %d %q", w.Code, w.Body.String())
+	}
+}
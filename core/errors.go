@@ -1,7 +1,10 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 )
 
@@ -23,6 +26,21 @@ const (
 	ErrorTypeNu = 2
 )
 
+// redactedErrorMessage replaces the message of errors that are not public in errorMsgs.JSON.
+const redactedErrorMessage = "internal error"
+
+// ErrFileTooLarge is returned by Context.SaveUploadedFile when a file exceeds Engine.MaxUploadFileSize.
+var ErrFileTooLarge = errors.New("vgo: uploaded file too large")
+
+// ErrBodyTooLarge is returned by Context.GetRawData when the body exceeds Engine.MaxRawDataSize.
+var ErrBodyTooLarge = errors.New("vgo: request body too large")
+
+// ErrUnsafeRedirect is returned by Context.SafeRedirect when the location points to a host that is not allowed.
+var ErrUnsafeRedirect = errors.New("vgo: unsafe redirect location")
+
+// ErrResponseDetached is returned when writing the response of a context created by Context.Copy.
+var ErrResponseDetached = errors.New("vgo: response writer of a copied context is detached")
+
 // Error represents a error's specification
 type Error struct {
 	Err  error
@@ -61,9 +79,9 @@ func (msg *Error) JSON() interface{} {
 		default:
 			jsonData["meta"] = msg.Meta
 		}
-		if _, ok := jsonData["error"]; ok {
-			jsonData["error"] = msg.Error()
-		}
+	}
+	if _, ok := jsonData["error"]; !ok {
+		jsonData["error"] = msg.Error()
 	}
 	return jsonData
 }
@@ -75,6 +93,9 @@ func (msg *Error) MarshalJSON() ([]byte, error) {
 
 // Error implements the error interface.
 func (msg Error) Error() string {
+	if msg.Err == nil {
+		return ""
+	}
 	return msg.Err.Error()
 }
 
@@ -86,4 +107,97 @@ func (msg *Error) IsType(flags ErrorType) bool {
 // Unwrap returns the wrapped error, to allow interoperability with errors.Is(), errors.As() and errors.Unwrap()
 func (msg *Error) Unwrap() error {
 	return msg.Err
-}
\ No newline at end of file
+}
+
+// ByType returns a readonly copy filtered the byte.
+// ie ByType(vgo.ErrorTypePublic) returns a slice of errors with type=ErrorTypePublic.
+func (a errorMsgs) ByType(typ ErrorType) errorMsgs {
+	if len(a) == 0 {
+		return nil
+	}
+	if typ == ErrorTypeAny {
+		// 返回副本，调用方修改结果不会影响context的错误列表
+		return append(errorMsgs(nil), a...)
+	}
+	var result errorMsgs
+	for _, msg := range a {
+		if msg.IsType(typ) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Last returns the last error in the slice. It returns nil if the array is empty.
+// Shortcut for errors[len(errors)-1].
+func (a errorMsgs) Last() *Error {
+	if length := len(a); length > 0 {
+		return a[length-1]
+	}
+	return nil
+}
+
+// Errors returns an array with all the error messages.
+// Example:
+//
+//	c.Error(errors.New("first"))
+//	c.Error(errors.New("second"))
+//	c.Error(errors.New("third"))
+//	c.Errors.Errors() // == []string{"first", "second", "third"}
+func (a errorMsgs) Errors() []string {
+	if len(a) == 0 {
+		return nil
+	}
+	errorStrings := make([]string, len(a))
+	for i, err := range a {
+		errorStrings[i] = err.Error()
+	}
+	return errorStrings
+}
+
+// JSON returns a value ready to be serialised: nil when there are no errors, a single object
+// for one error and an array otherwise. Only errors of type ErrorTypePublic keep their message
+// and meta data, every other error, ErrorTypePrivate in particular, is reduced to
+// {"error": "internal error"} so that it is never leaked to the client.
+func (a errorMsgs) JSON() interface{} {
+	switch length := len(a); length {
+	case 0:
+		return nil
+	case 1:
+		return a[0].publicJSON()
+	default:
+		jsonData := make([]interface{}, length)
+		for i, err := range a {
+			jsonData[i] = err.publicJSON()
+		}
+		return jsonData
+	}
+}
+
+// publicJSON 公开错误返回完整的JSON，其它错误只返回脱敏后的信息
+func (msg *Error) publicJSON() interface{} {
+	if msg.IsType(ErrorTypePublic) {
+		return msg.JSON()
+	}
+	return H{"error": redactedErrorMessage}
+}
+
+// MarshalJSON implements the json.Marshaller interface.
+func (a errorMsgs) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.JSON())
+}
+
+// String returns every error with its position and meta data, one per line, for logging.
+func (a errorMsgs) String() string {
+	if len(a) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	for i, msg := range a {
+		fmt.Fprintf(&buffer, "Error #%02d: %s\n", i+1, msg.Error())
+		if msg.Meta != nil {
+			fmt.Fprintf(&buffer, "     Meta: %v\n", msg.Meta)
+		}
+	}
+	return buffer.String()
+}
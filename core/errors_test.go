@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContextError 测试 Context.Error 收集错误，nil错误会panic
+func TestContextError(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if c.Errors.Last() != nil || c.Errors.Errors() != nil || c.Errors.JSON() != nil || c.Errors.String() != "" {
+		t.Fatal("没有错误时应该返回空值")
+	}
+
+	c.Error(errors.New("first"))
+	public := &Error{Err: errors.New("second"), Type: ErrorTypePublic}
+	if c.Error(public) != public {
+		t.Fatal("*Error 应该原样加入错误列表")
+	}
+	c.AbortWithError(http.StatusUnauthorized, errors.New("third")).SetType(ErrorTypePublic).SetMeta("auth")
+
+	if c.StatusCode != http.StatusUnauthorized || !c.IsAborted() {
+		t.Fatal("AbortWithError 应该终止并写入状态码")
+	}
+	if c.Errors.Last().Error() != "third" || len(c.Errors.Errors()) != 3 {
+		t.Fatalf("错误列表不正确: %v", c.Errors.Errors())
+	}
+	if private := c.Errors.ByType(ErrorTypePrivate); len(private) != 1 || private[0].Error() != "first" {
+		t.Fatalf("ByType 过滤不正确: %v", private.Errors())
+	}
+	if len(c.Errors.ByType(ErrorTypePublic)) != 2 || len(c.Errors.ByType(ErrorTypeAny)) != 3 {
+		t.Fatal("ByType 过滤不正确")
+	}
+	all := c.Errors.ByType(ErrorTypeAny)
+	all[0] = &Error{Err: errors.New("changed")}
+	if c.Errors[0].Error() != "first" {
+		t.Fatal("修改 ByType 的结果不应该影响原错误列表")
+	}
+	want := "Error #01: first\nError #02: second\nError #03: third\n     Meta: auth\n"
+	if c.Errors.String() != want {
+		t.Fatalf("String 格式不正确: %q", c.Errors.String())
+	}
+	if s := (errorMsgs{{}}).String(); s != "Error #01: \n" {
+		t.Fatalf("Err 为nil时 String 应该与 Error 一致: %q", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("nil错误应该panic")
+		}
+	}()
+	c.Error(nil)
+}
+
+// TestErrorsJSON 测试序列化时公开错误保留信息，私有错误被脱敏
+func TestErrorsJSON(t *testing.T) {
+	errs := errorMsgs{
+		{Err: errors.New("db password wrong"), Type: ErrorTypePrivate},
+		{Err: errors.New("name required"), Type: ErrorTypePublic, Meta: H{"field": "name"}},
+		{Err: errors.New("bad json"), Type: ErrorTypeBind | ErrorTypePublic, Meta: 1},
+	}
+	data, err := json.Marshal(errs)
+	want := `[{"error":"internal error"},{"error":"name required","field":"name"},{"error":"bad json","meta":1}]`
+	if err != nil || string(data) != want {
+		t.Fatalf("JSON 不正确: %s %v", data, err)
+	}
+
+	data, _ = json.Marshal(errs[1:2])
+	if string(data) != `{"error":"name required","field":"name"}` {
+		t.Fatalf("单个错误应该序列化为对象: %s", data)
+	}
+
+	if (&Error{}).Error() != "" {
+		t.Fatal("Err为nil时 Error 不应该panic")
+	}
+}
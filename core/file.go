@@ -0,0 +1,114 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File writes the specified file into the body stream in an efficient way.
+// Range requests, If-Modified-Since and If-None-Match are answered by http.ServeContent.
+func (c *Context) File(filepath string) {
+	f, err := os.Open(filepath)
+	c.serveFile(f, err, "")
+}
+
+// FileFromFS writes the specified file from http.FileSystem into the body stream in an efficient way.
+func (c *Context) FileFromFS(filepath string, fs http.FileSystem) {
+	f, err := fs.Open(filepath)
+	c.serveFile(f, err, "")
+}
+
+// FileAttachment writes the specified file into the body stream in an efficient way
+// On the client side, the file will typically be downloaded with the given filename.
+// Non-ASCII filenames are encoded as described in RFC 6266.
+func (c *Context) FileAttachment(filepath, filename string) {
+	f, err := os.Open(filepath)
+	c.serveFile(f, err, contentDisposition("attachment", filename))
+}
+
+// serveFile 将打开的文件交由 http.ServeContent 处理条件请求与范围请求
+// disposition 不为空时在确认文件存在后设置 Content-Disposition，避免404响应也被当作附件下载
+func (c *Context) serveFile(f http.File, err error, disposition string) {
+	if err != nil {
+		c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		return
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+		return
+	}
+
+	if disposition != "" {
+		c.SetHeader("Content-Disposition", disposition)
+	}
+	if c.Writer.Header().Get("ETag") == "" {
+		c.SetHeader("ETag", fileETag(stat))
+	}
+	http.ServeContent(c.Writer, c.Req, stat.Name(), stat.ModTime(), f)
+}
+
+// fileETag 根据文件大小与修改时间生成强ETag，弱ETag不能用于 If-Range 的范围请求
+func fileETag(stat os.FileInfo) string {
+	return fmt.Sprintf(`"%x-%x"`, stat.Size(), stat.ModTime().UnixNano())
+}
+
+// contentDisposition 生成 Content-Disposition 头，非ASCII文件名使用 RFC 5987 的 filename* 参数，
+// 同时保留一个ASCII的 filename 参数兼容旧客户端
+func contentDisposition(dispositionType, filename string) string {
+	filename = filepath.Base(filename)
+	fallback := make([]byte, 0, len(filename))
+	ascii := true
+	for i := 0; i < len(filename); i++ {
+		b := filename[i]
+		switch {
+		case b >= 0x80:
+			ascii = false
+			// 一个多字节字符只保留一个占位符
+			if b >= 0xC0 {
+				fallback = append(fallback, '_')
+			}
+		case b < 0x20 || b == 0x7F:
+			fallback = append(fallback, '_')
+		case b == '"' || b == '\\':
+			fallback = append(fallback, '\\', b)
+		default:
+			fallback = append(fallback, b)
+		}
+	}
+	disposition := dispositionType + `; filename="` + string(fallback) + `"`
+	if !ascii {
+		disposition += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return disposition
+}
+
+// encodeRFC5987 按 RFC 5987 的 attr-char 规则对参数值进行百分号编码
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if isAttrChar(ch) {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[ch>>4])
+		b.WriteByte(hex[ch&0x0F])
+	}
+	return b.String()
+}
+
+func isAttrChar(ch byte) bool {
+	switch {
+	case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", ch) >= 0
+}
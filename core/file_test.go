@@ -0,0 +1,126 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestContextFile 测试文件响应的范围请求与条件请求
+func TestContextFile(t *testing.T) {
+	r := New()
+	r.GET("/file", func(c *Context) {
+		c.File("testdata/static/hello.txt")
+	})
+
+	w := performRequest(r, "GET", "/file")
+	if w.Code != http.StatusOK || w.Body.String() != "hello vgo\n" {
+		t.Fatalf("文件响应不正确: %d %q", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	if etag == "" || strings.HasPrefix(etag, "W/") || lastModified == "" {
+		t.Fatalf("文件响应应该包含强 ETag 与 Last-Modified: %q", etag)
+	}
+
+	req := httptest.NewRequest("GET", "/file", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "hello" {
+		t.Fatalf("范围请求响应不正确: %d %q", w.Code, w.Body.String())
+	}
+
+	// If-Range 只接受强ETag，ETag一致时返回范围内容
+	req = httptest.NewRequest("GET", "/file", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	req.Header.Set("If-Range", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "hello" {
+		t.Fatalf("If-Range 命中时应该返回范围内容: %d %q", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/file", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match 命中时应该返回304, 实际为 %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/file", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since 命中时应该返回304, 实际为 %d", w.Code)
+	}
+
+	r.GET("/missing", func(c *Context) {
+		c.File("testdata/static/missing.txt")
+	})
+	if w = performRequest(r, "GET", "/missing"); w.Code != http.StatusNotFound {
+		t.Fatalf("文件不存在时应该返回404, 实际为 %d", w.Code)
+	}
+}
+
+// TestContextFileAttachment 测试附件下载的文件名编码
+func TestContextFileAttachment(t *testing.T) {
+	cases := []struct {
+		filename string
+		want     string
+	}{
+		{"log.txt", `attachment; filename="log.txt"`},
+		{`a"b.txt`, `attachment; filename="a\"b.txt"`},
+		{"日志 2021.txt", `attachment; filename="__ 2021.txt"; filename*=UTF-8''%E6%97%A5%E5%BF%97%202021.txt`},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+		c.FileAttachment("testdata/static/hello.txt", tc.filename)
+		if got := w.Header().Get("Content-Disposition"); got != tc.want {
+			t.Fatalf("Content-Disposition 应该为 %q, 实际为 %q", tc.want, got)
+		}
+		if w.Body.String() != "hello vgo\n" {
+			t.Fatalf("附件内容不正确: %q", w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.FileAttachment("testdata/static/missing.txt", "missing.txt")
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("文件不存在时不应该设置 Content-Disposition: %d %q", w.Code, w.Header().Get("Content-Disposition"))
+	}
+}
+
+// TestContextFileFromFS 测试从http.FileSystem返回文件
+func TestContextFileFromFS(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.FileFromFS("css/app.css", http.Dir("testdata/static"))
+	if w.Code != http.StatusOK || w.Body.String() != "body{}\n" {
+		t.Fatalf("FileFromFS响应不正确: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestContextDataFromReader 测试流式返回数据
+func TestContextDataFromReader(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	body := "streaming body"
+	c.DataFromReader(http.StatusOK, int64(len(body)), "text/plain", strings.NewReader(body),
+		map[string]string{"Content-Disposition": `attachment; filename="log.txt"`})
+
+	if w.Body.String() != body {
+		t.Fatalf("响应体不正确: %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Length") != "14" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("响应头不正确: %v", w.Header())
+	}
+	if w.Header().Get("Content-Disposition") != `attachment; filename="log.txt"` {
+		t.Fatalf("额外响应头不正确: %v", w.Header())
+	}
+}
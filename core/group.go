@@ -33,16 +33,16 @@ func (group *GroupRouter) Use(middlewares ...HandlerFunc) {
 }
 
 // addRoute 分组添加路由
-func (group *GroupRouter) addRoute(method string, comp string, handler HandlerFunc) {
+func (group *GroupRouter) addRoute(method string, comp string, handler HandlerFunc) *RouteInfo {
 	pattern := group.prefix + comp
 	log.Printf("Route %4s - %s", method, pattern)
-	group.engine.router.addRoute(method, pattern, handler)
+	return group.engine.router.addRoute(method, pattern, handler)
 }
 
-func (group *GroupRouter) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+func (group *GroupRouter) GET(pattern string, handler HandlerFunc) *RouteInfo {
+	return group.addRoute("GET", pattern, handler)
 }
 
-func (group *GroupRouter) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *GroupRouter) POST(pattern string, handler HandlerFunc) *RouteInfo {
+	return group.addRoute("POST", pattern, handler)
 }
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// Delims represents a set of Left and Right delimiters for HTML template rendering.
+type Delims struct {
+	// Left delimiter, defaults to {{.
+	Left string
+	// Right delimiter, defaults to }}.
+	Right string
+}
+
+// htmlTemplates 按名称执行模板的模板集合
+type htmlTemplates interface {
+	execute(w io.Writer, name string, data interface{}) error
+}
+
+// templateSet 普通模板集合，所有模板共享同一个命名空间，按 {{define}} 名称或文件名查找
+type templateSet struct {
+	*template.Template
+}
+
+func (t templateSet) execute(w io.Writer, name string, data interface{}) error {
+	if name == "" {
+		return t.Execute(w, data)
+	}
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// layoutSet 布局模板集合，每个页面与布局文件组成独立的模板集，key为页面文件名
+type layoutSet map[string]*template.Template
+
+func (l layoutSet) execute(w io.Writer, name string, data interface{}) error {
+	t, ok := l[name]
+	if !ok {
+		return fmt.Errorf("html/template: no layout page %q is defined", name)
+	}
+	return t.Execute(w, data)
+}
+
+// newTemplate 创建一个带有引擎分隔符与函数的根模板
+func (engine *Engine) newTemplate(name string) *template.Template {
+	return template.New(name).Delims(engine.delims.Left, engine.delims.Right).Funcs(engine.funcMap)
+}
+
+// loadHTML 执行模板加载函数并保存，HTMLDebug模式下每次渲染都会重新调用加载函数
+func (engine *Engine) loadHTML(loader func() (htmlTemplates, error)) {
+	html, err := loader()
+	if err != nil {
+		panic(err)
+	}
+	engine.htmlLoader = loader
+	engine.html = html
+}
+
+// Delims sets template left and right delims and returns an Engine instance.
+func (engine *Engine) Delims(left, right string) *Engine {
+	engine.delims = Delims{Left: left, Right: right}
+	return engine
+}
+
+// SetFuncMap sets the FuncMap used for template.FuncMap.
+func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
+	engine.funcMap = funcMap
+}
+
+// LoadHTMLGlob loads HTML files identified by glob pattern
+// and associates the result with HTML renderer.
+func (engine *Engine) LoadHTMLGlob(pattern string) {
+	engine.loadHTML(func() (htmlTemplates, error) {
+		t, err := engine.newTemplate("").ParseGlob(pattern)
+		return templateSet{t}, err
+	})
+}
+
+// LoadHTMLFiles loads a slice of HTML files
+// and associates the result with HTML renderer.
+func (engine *Engine) LoadHTMLFiles(files ...string) {
+	engine.loadHTML(func() (htmlTemplates, error) {
+		t, err := engine.newTemplate("").ParseFiles(files...)
+		return templateSet{t}, err
+	})
+}
+
+// LoadHTMLFS loads HTML files matching the patterns from a file system, such as an embed.FS,
+// and associates the result with HTML renderer.
+func (engine *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	engine.loadHTML(func() (htmlTemplates, error) {
+		t, err := engine.newTemplate("").ParseFS(fsys, patterns...)
+		return templateSet{t}, err
+	})
+}
+
+// LoadHTMLLayouts parses every page together with the layout files, so that each page can
+// fill the blocks of the layout. The first layout file is the one executed, and the resulting
+// templates are rendered by the base name of the page file, e.g. c.HTML(200, "login.html", nil).
+func (engine *Engine) LoadHTMLLayouts(layouts []string, pages ...string) {
+	if len(layouts) == 0 {
+		panic("html/template: at least one layout file is required")
+	}
+	engine.loadHTML(func() (htmlTemplates, error) {
+		set := make(layoutSet, len(pages))
+		for _, page := range pages {
+			files := append(append([]string{}, layouts...), page)
+			t, err := engine.newTemplate(filepath.Base(layouts[0])).ParseFiles(files...)
+			if err != nil {
+				return nil, err
+			}
+			set[filepath.Base(page)] = t
+		}
+		return set, nil
+	})
+}
+
+// SetHTMLTemplate associate a template with HTML renderer.
+// Templates set this way are never re-parsed, even in HTMLDebug mode.
+func (engine *Engine) SetHTMLTemplate(templ *template.Template) {
+	engine.htmlLoader = nil
+	engine.html = templateSet{templ.Funcs(engine.funcMap)}
+}
+
+// executeHTML 执行指定名称的模板，HTMLDebug模式下先重新解析模板文件
+func (engine *Engine) executeHTML(w io.Writer, name string, data interface{}) error {
+	html := engine.html
+	if engine.HTMLDebug && engine.htmlLoader != nil {
+		var err error
+		if html, err = engine.htmlLoader(); err != nil {
+			return err
+		}
+	}
+	if html == nil {
+		return fmt.Errorf("html/template: no templates are loaded, call LoadHTMLGlob or LoadHTMLFiles first")
+	}
+	return html.execute(w, name, data)
+}
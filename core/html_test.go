@@ -0,0 +1,118 @@
+package core
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func performRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestLoadHTMLGlob 测试模板函数与自定义分隔符
+func TestLoadHTMLGlob(t *testing.T) {
+	r := New()
+	r.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	r.LoadHTMLGlob("testdata/template/*.tmpl")
+	r.GET("/hello", func(c *Context) {
+		c.HTML(http.StatusOK, "hello.tmpl", H{"name": "vgo"})
+	})
+
+	w := performRequest(r, "GET", "/hello")
+	if w.Code != http.StatusOK || w.Body.String() != "<p>VGO</p>" {
+		t.Fatalf("模板渲染结果不正确: %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatalf("Content-Type不正确: %q", ct)
+	}
+
+	r = New()
+	r.Delims("{[{", "}]}")
+	r.LoadHTMLFiles("testdata/template/delims.tmpl")
+	r.GET("/delims", func(c *Context) {
+		c.HTML(http.StatusOK, "delims.tmpl", H{"name": "<vgo>"})
+	})
+	if body := performRequest(r, "GET", "/delims").Body.String(); body != "<h1>Hello &lt;vgo&gt;</h1>" {
+		t.Fatalf("自定义分隔符渲染结果不正确: %q", body)
+	}
+}
+
+// TestLoadHTMLLayouts 测试页面填充布局模板
+func TestLoadHTMLLayouts(t *testing.T) {
+	r := New()
+	r.LoadHTMLLayouts([]string{"testdata/template/layout/base.html"},
+		"testdata/template/layout/login.html", "testdata/template/layout/list.html")
+	r.GET("/login", func(c *Context) {
+		c.HTML(http.StatusOK, "login.html", H{"user": "pjx"})
+	})
+	r.GET("/list", func(c *Context) {
+		c.HTML(http.StatusOK, "list.html", []string{"a", "b"})
+	})
+	r.GET("/missing", func(c *Context) {
+		c.HTML(http.StatusOK, "missing.html", nil)
+	})
+
+	if body := performRequest(r, "GET", "/login").Body.String(); body != "<html><title>login</title><body><form>pjx</form></body></html>" {
+		t.Fatalf("login页面渲染结果不正确: %q", body)
+	}
+	if body := performRequest(r, "GET", "/list").Body.String(); body != "<html><title>list</title><body><ul><li>a</li><li>b</li></ul></body></html>" {
+		t.Fatalf("list页面渲染结果不正确: %q", body)
+	}
+	if w := performRequest(r, "GET", "/missing"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("模板不存在时应该返回500, 实际为 %d", w.Code)
+	}
+}
+
+// TestLoadHTMLFS 测试从文件系统（如embed.FS）加载模板
+func TestLoadHTMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/index.html": {Data: []byte(`{{ define "index" }}index {{ . }}{{ end }}`)},
+	}
+	r := New()
+	r.LoadHTMLFS(fsys, "views/*.html")
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "index", "vgo")
+	})
+	if body := performRequest(r, "GET", "/").Body.String(); body != "index vgo" {
+		t.Fatalf("FS模板渲染结果不正确: %q", body)
+	}
+}
+
+// TestHTMLDebug 测试调试模式下每次请求重新解析模板
+func TestHTMLDebug(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vgo-html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "index.html")
+	if err = ioutil.WriteFile(file, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	r.LoadHTMLFiles(file)
+	r.GET("/", func(c *Context) {
+		c.HTML(http.StatusOK, "index.html", nil)
+	})
+	if err = ioutil.WriteFile(file, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if body := performRequest(r, "GET", "/").Body.String(); body != "v1" {
+		t.Fatalf("非调试模式不应该重新解析模板: %q", body)
+	}
+	r.HTMLDebug = true
+	if body := performRequest(r, "GET", "/").Body.String(); body != "v2" {
+		t.Fatalf("调试模式应该重新解析模板: %q", body)
+	}
+}
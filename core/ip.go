@@ -0,0 +1,162 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetTrustedProxies set a list of network origins (IPv4 addresses,
+// IPv4 CIDRs, IPv6 addresses or IPv6 CIDRs) from which to trust
+// request's headers that contain alternative client IP when
+// `(*Context).ClientIP()` is called. No proxy is trusted by default,
+// so the forwarding headers are ignored until this method is called,
+// e.g. engine.SetTrustedProxies([]string{"127.0.0.1", "10.0.0.0/8"}).
+// Passing nil disables the feature again.
+func (engine *Engine) SetTrustedProxies(trustedProxies []string) error {
+	cidrs, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedCIDRs = cidrs
+	return nil
+}
+
+// parseTrustedProxies 将IP或CIDR字符串解析为网段，单个IP视为 /32 或 /128
+func parseTrustedProxies(trustedProxies []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, trustedProxy := range trustedProxies {
+		if !strings.Contains(trustedProxy, "/") {
+			ip := net.ParseIP(trustedProxy)
+			if ip == nil {
+				return nil, fmt.Errorf("vgo: invalid trusted proxy %q", trustedProxy)
+			}
+			if ip.To4() != nil {
+				trustedProxy += "/32"
+			} else {
+				trustedProxy += "/128"
+			}
+		}
+		_, cidrNet, err := net.ParseCIDR(trustedProxy)
+		if err != nil {
+			return nil, fmt.Errorf("vgo: invalid trusted proxy %q: %w", trustedProxy, err)
+		}
+		cidrs = append(cidrs, cidrNet)
+	}
+	return cidrs, nil
+}
+
+// isTrustedProxy will check whether the IP address is included in the trusted list according to Engine.trustedCIDRs
+func (engine *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range engine.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHeader will parse X-Forwarded-For header and return the trusted client IP address.
+// The chain is walked right-to-left, since every proxy appends the address it received the request from,
+// and the first untrusted hop is the client.
+func (engine *Engine) validateHeader(header string) (clientIP string, valid bool) {
+	if header == "" {
+		return "", false
+	}
+	items := strings.Split(header, ",")
+	for i := len(items) - 1; i >= 0; i-- {
+		ipStr := strings.TrimSpace(items[i])
+		ip := net.ParseIP(ipStr)
+		if ip == nil {
+			break
+		}
+
+		// X-Forwarded-For is appended by proxy
+		// Check IPs in reverse order and stop when find untrusted proxy
+		if (i == 0) || (!engine.isTrustedProxy(ip)) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// fromTrustedProxy 判断当前请求是否由可信代理转发
+func (c *Context) fromTrustedProxy() bool {
+	if c.engine == nil {
+		return false
+	}
+	ip := net.ParseIP(c.RemoteIP())
+	return ip != nil && c.engine.isTrustedProxy(ip)
+}
+
+// ClientIP implements one best effort algorithm to return the real client IP.
+// When the request comes from a trusted proxy, the headers in Engine.RemoteIPHeaders are checked in order,
+// e.g. X-Forwarded-For, X-Real-IP or CF-Connecting-IP. Otherwise, or when none of them holds a valid IP,
+// the remote IP (coming from Request.RemoteAddr) is returned.
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	if remoteIP == "" {
+		return ""
+	}
+	if c.fromTrustedProxy() {
+		for _, headerName := range c.engine.RemoteIPHeaders {
+			// 代理可能追加新的一行而不是合并到已有的头中，多行按顺序拼接后再从右向左查找
+			if ip, valid := c.engine.validateHeader(strings.Join(c.Req.Header.Values(headerName), ",")); valid {
+				return ip
+			}
+		}
+	}
+	return remoteIP
+}
+
+// RemoteIP parses the IP from Request.RemoteAddr, normalizes and returns the IP (without the port).
+func (c *Context) RemoteIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		return ""
+	}
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ""
+}
+
+// Scheme returns the scheme the client used, "http" or "https".
+// X-Forwarded-Proto is only respected when the request comes from a trusted proxy.
+func (c *Context) Scheme() string {
+	if c.fromTrustedProxy() {
+		switch proto := strings.ToLower(c.lastForwardedValue("X-Forwarded-Proto")); proto {
+		case "http", "https":
+			return proto
+		}
+	}
+	if c.Req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host the client requested.
+// X-Forwarded-Host is only respected when the request comes from a trusted proxy.
+func (c *Context) Host() string {
+	if c.fromTrustedProxy() {
+		if host := c.lastForwardedValue("X-Forwarded-Host"); host != "" {
+			return host
+		}
+	}
+	return c.Req.Host
+}
+
+// lastForwardedValue 取出逗号分隔的转发头中最右边的值
+// 左边的值可能由客户端伪造，最右边的值由离服务最近的可信代理追加
+func (c *Context) lastForwardedValue(key string) string {
+	values := c.Req.Header.Values(key)
+	if len(values) == 0 {
+		return ""
+	}
+	value := values[len(values)-1]
+	if i := strings.LastIndexByte(value, ','); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestIPContext(engine *Engine, remoteAddr string, headers map[string]string) *Context {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = remoteAddr
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	c := NewContext(httptest.NewRecorder(), req)
+	c.engine = engine
+	return c
+}
+
+// TestContextClientIP 测试经过可信代理时解析客户端IP
+func TestContextClientIP(t *testing.T) {
+	r := New()
+	headers := map[string]string{
+		"X-Forwarded-For":  " 20.20.20.20, 30.30.30.30, 10.0.0.2",
+		"X-Real-IP":        "10.10.10.10",
+		"CF-Connecting-IP": "50.50.50.50",
+	}
+
+	c := newTestIPContext(r, "10.0.0.1:8080", headers)
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Fatalf("默认不信任任何代理，ClientIP 应该为 10.0.0.1, 实际为 %s", ip)
+	}
+
+	if err := r.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+	if ip := c.ClientIP(); ip != "30.30.30.30" {
+		t.Fatalf("应该从右向左找到第一个不可信的地址, 实际为 %s", ip)
+	}
+
+	if err := r.SetTrustedProxies([]string{"10.0.0.0/8", "30.30.30.30"}); err != nil {
+		t.Fatal(err)
+	}
+	if ip := c.ClientIP(); ip != "20.20.20.20" {
+		t.Fatalf("ClientIP 应该为 20.20.20.20, 实际为 %s", ip)
+	}
+
+	r.RemoteIPHeaders = []string{"CF-Connecting-IP", "X-Forwarded-For"}
+	if ip := c.ClientIP(); ip != "50.50.50.50" {
+		t.Fatalf("应该按 RemoteIPHeaders 的顺序解析, 实际为 %s", ip)
+	}
+
+	c = newTestIPContext(r, "40.40.40.40:8080", headers)
+	if ip := c.ClientIP(); ip != "40.40.40.40" {
+		t.Fatalf("来自不可信地址的请求应该忽略转发头, 实际为 %s", ip)
+	}
+
+	c = newTestIPContext(r, "10.0.0.1:8080", map[string]string{"X-Forwarded-For": "not an ip"})
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Fatalf("转发头无效时应该返回 RemoteIP, 实际为 %s", ip)
+	}
+
+	c = newTestIPContext(r, "[::1]:8080", nil)
+	if ip := c.RemoteIP(); ip != "::1" {
+		t.Fatalf("RemoteIP 应该为 ::1, 实际为 %s", ip)
+	}
+
+	// 代理追加了新的一行 X-Forwarded-For，客户端伪造的第一行不应该被采用
+	r = New()
+	_ = r.SetTrustedProxies([]string{"10.0.0.0/8"})
+	c = newTestIPContext(r, "10.0.0.1:8080", nil)
+	c.Req.Header.Add("X-Forwarded-For", "1.1.1.1")
+	c.Req.Header.Add("X-Forwarded-For", "30.30.30.30, 10.0.0.2")
+	if ip := c.ClientIP(); ip != "30.30.30.30" {
+		t.Fatalf("多行 X-Forwarded-For 应该合并后从右向左查找, 实际为 %s", ip)
+	}
+
+	if err := r.SetTrustedProxies([]string{"bad proxy"}); err == nil {
+		t.Fatal("无效的可信代理应该返回错误")
+	}
+}
+
+// TestContextSchemeAndHost 测试可信代理的 X-Forwarded-Proto 与 X-Forwarded-Host
+func TestContextSchemeAndHost(t *testing.T) {
+	r := New()
+	// 左边的值可能由客户端伪造，只使用可信代理追加的最右边的值
+	headers := map[string]string{"X-Forwarded-Proto": "http, HTTPS", "X-Forwarded-Host": "evil.com, vgo.dev"}
+
+	c := newTestIPContext(r, "10.0.0.1:8080", headers)
+	if c.Scheme() != "http" || c.Host() != "example.com" {
+		t.Fatalf("不可信代理的转发头应该被忽略: %s %s", c.Scheme(), c.Host())
+	}
+
+	_ = r.SetTrustedProxies([]string{"10.0.0.1"})
+	if c.Scheme() != "https" || c.Host() != "vgo.dev" {
+		t.Fatalf("可信代理的转发头应该生效: %s %s", c.Scheme(), c.Host())
+	}
+
+	c = newTestIPContext(r, "40.40.40.40:8080", headers)
+	c.Req.TLS = &tls.ConnectionState{}
+	if c.Scheme() != "https" {
+		t.Fatal("TLS请求的 Scheme 应该为 https")
+	}
+}
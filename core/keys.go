@@ -0,0 +1,61 @@
+package core
+
+import "fmt"
+
+// Key is a typed key for the key store of Context. Keys are compared by identity,
+// so two middleware packages declaring a key with the same name never overwrite
+// each other's values, and the value type is checked at compile time.
+//
+//	var userKey = core.NewKey[*User]("user")
+//
+//	userKey.Set(c, user)
+//	user, ok := userKey.Get(c)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey creates a typed key, the name is only used for debugging and panic messages.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set stores the value for the key in c.
+func (k *Key[T]) Set(c *Context, value T) {
+	c.setKey(k, value)
+}
+
+// Get returns the value stored for the key in c, ie: (value, true).
+// If the value does not exist it returns the zero value of T and false.
+func (k *Key[T]) Get(c *Context) (value T, ok bool) {
+	val, exists := c.getKey(k)
+	if !exists {
+		return
+	}
+	value, ok = val.(T)
+	return
+}
+
+// MustGet returns the value stored for the key in c, otherwise it panics.
+func (k *Key[T]) MustGet(c *Context) T {
+	value, ok := k.Get(c)
+	if !ok {
+		panic(fmt.Sprintf("Key %q does not exist", k.name))
+	}
+	return value
+}
+
+// Value returns the value stored with Context.Set under key as type T.
+// ok is false when the key does not exist or the value is not a T.
+func Value[T any](c *Context, key string) (value T, ok bool) {
+	val, exists := c.Get(key)
+	if !exists {
+		return
+	}
+	value, ok = val.(T)
+	return
+}
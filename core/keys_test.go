@@ -0,0 +1,100 @@
+package core
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestContextKeysConcurrent 测试并发读写键值对，需要配合 go test -race 运行
+func TestContextKeysConcurrent(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			c.Set("key"+strconv.Itoa(i), i)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			c.Get("key" + strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 50; i++ {
+		if c.GetInt("key"+strconv.Itoa(i)) != i {
+			t.Fatalf("key%d 的值不正确", i)
+		}
+	}
+}
+
+// TestContextGetters 测试类型化的取值方法
+func TestContextGetters(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.Set("duration", time.Second)
+	c.Set("slice", []string{"a", "b"})
+	c.Set("map", map[string]interface{}{"a": 1})
+	c.Set("mapString", map[string]string{"a": "b"})
+	c.Set("mapSlice", map[string][]string{"a": {"b"}})
+
+	if c.GetDuration("duration") != time.Second || c.GetDuration("slice") != 0 {
+		t.Fatal("GetDuration 结果不正确")
+	}
+	if !reflect.DeepEqual(c.GetStringSlice("slice"), []string{"a", "b"}) {
+		t.Fatal("GetStringSlice 结果不正确")
+	}
+	if c.GetStringMap("map")["a"] != 1 || c.GetStringMapString("mapString")["a"] != "b" {
+		t.Fatal("GetStringMap 结果不正确")
+	}
+	if c.GetStringMapStringSlice("mapSlice")["a"][0] != "b" {
+		t.Fatal("GetStringMapStringSlice 结果不正确")
+	}
+	if c.MustGet("duration") != time.Second {
+		t.Fatal("MustGet 结果不正确")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustGet 对于不存在的key应该panic")
+		}
+	}()
+	c.MustGet("missing")
+}
+
+// TestTypedKeys 测试泛型取值与带类型的key
+func TestTypedKeys(t *testing.T) {
+	c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	c.Set("user", "pjx")
+	if v, ok := Value[string](c, "user"); !ok || v != "pjx" {
+		t.Fatal("Value[string] 结果不正确")
+	}
+	if v, ok := Value[int](c, "user"); ok || v != 0 {
+		t.Fatal("类型不匹配时 Value 应该返回零值与false")
+	}
+
+	// 两个中间件包使用同名的key互不影响，也不会与字符串key冲突
+	authUser := NewKey[int]("user")
+	auditUser := NewKey[string]("user")
+	authUser.Set(c, 42)
+	auditUser.Set(c, "audit")
+	if id, ok := authUser.Get(c); !ok || id != 42 {
+		t.Fatal("带类型的key取值不正确")
+	}
+	if name := auditUser.MustGet(c); name != "audit" {
+		t.Fatal("同名的key不应该相互覆盖")
+	}
+	if c.GetString("user") != "pjx" {
+		t.Fatal("带类型的key不应该覆盖字符串key")
+	}
+	if _, ok := NewKey[int]("user").Get(c); ok {
+		t.Fatal("新建的同名key不应该取到其他key的值")
+	}
+
+	cp := c.Copy()
+	if id, _ := authUser.Get(cp); id != 42 {
+		t.Fatal("Copy 应该复制带类型的key")
+	}
+}
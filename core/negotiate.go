@@ -0,0 +1,259 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content-Type MIME of the most common data formats.
+const (
+	MIMEJSON      = "application/json"
+	MIMEHTML      = "text/html"
+	MIMEXML       = "application/xml"
+	MIMEXML2      = "text/xml"
+	MIMEPlain     = "text/plain"
+	MIMEYAML      = "application/x-yaml"
+	MIMEYAML2     = "application/yaml"
+	MIMEPOSTForm  = "application/x-www-form-urlencoded"
+	MIMEMultipart = "multipart/form-data"
+)
+
+// Negotiate contains all negotiations data.
+type Negotiate struct {
+	Offered   []string
+	HTMLName  string
+	HTMLData  interface{}
+	JSONData  interface{}
+	XMLData   interface{}
+	YAMLData  interface{}
+	PlainData interface{}
+	Data      interface{}
+}
+
+// acceptRange 表示Accept头中的一项媒体范围，例如 text/*;q=0.8
+type acceptRange struct {
+	typ     string
+	subtype string
+	q       float64
+}
+
+// specificity 返回媒体范围的精确程度：*/* 为0，type/* 为1，type/subtype 为2
+func (r acceptRange) specificity() int {
+	switch {
+	case r.typ == "*":
+		return 0
+	case r.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+// match 判断媒体范围是否匹配给定的MIME类型
+func (r acceptRange) match(typ, subtype string) bool {
+	if r.typ == "*" {
+		return true
+	}
+	if r.typ != typ {
+		return false
+	}
+	return r.subtype == "*" || r.subtype == subtype
+}
+
+// splitMediaType 将MIME类型拆分为类型和子类型，并忽略参数部分，例如 text/html; charset=utf-8
+func splitMediaType(mime string) (typ, subtype string) {
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	mime = strings.ToLower(strings.TrimSpace(mime))
+	if i := strings.IndexByte(mime, '/'); i >= 0 {
+		return mime[:i], mime[i+1:]
+	}
+	if mime == "*" {
+		return "*", "*"
+	}
+	return mime, ""
+}
+
+// parseAccept 解析Accept头，返回按q值从高到低排序的媒体范围，q值相同时保持客户端给出的顺序
+func parseAccept(header string) []acceptRange {
+	parts := strings.Split(header, ",")
+	ranges := make([]acceptRange, 0, len(parts))
+	for _, part := range parts {
+		params := strings.Split(part, ";")
+		typ, subtype := splitMediaType(params[0])
+		if typ == "" || subtype == "" {
+			continue
+		}
+		r := acceptRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) < 2 || (param[0] != 'q' && param[0] != 'Q') || param[1] != '=' {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(param[2:]), 64)
+			if err != nil || q < 0 || q > 1 {
+				q = 0
+			}
+			r.q = q
+		}
+		ranges = append(ranges, r)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// qualityOf 返回MIME类型在Accept列表中的q值：取最精确的匹配项，没有匹配项时返回0
+func qualityOf(ranges []acceptRange, mime string) float64 {
+	typ, subtype := splitMediaType(mime)
+	best, q := -1, 0.0
+	for _, r := range ranges {
+		if !r.match(typ, subtype) {
+			continue
+		}
+		if s := r.specificity(); s > best {
+			best, q = s, r.q
+		}
+	}
+	return q
+}
+
+// NegotiateFormat returns an acceptable Accept format.
+// The Accept header is parsed with its q-values and wildcards, a more specific media range takes
+// precedence over a wildcard one, and offers of equal quality are picked in the server's order.
+// An empty string is returned when none of the offered formats is acceptable.
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		panic("you must provide at least one offer")
+	}
+	if formats := c.acceptableFormats(offered); len(formats) > 0 {
+		return formats[0]
+	}
+	return ""
+}
+
+// acceptableFormats 返回客户端可以接受的格式，按q值从高到低排序，q值相同时保持服务端给出的顺序
+func (c *Context) acceptableFormats(offered []string) []string {
+	var ranges []acceptRange
+	if c.Accepted != nil {
+		ranges = make([]acceptRange, 0, len(c.Accepted))
+		for _, accepted := range c.Accepted {
+			ranges = append(ranges, parseAccept(accepted)...)
+		}
+	} else if c.Req != nil {
+		ranges = parseAccept(c.Req.Header.Get("Accept"))
+	}
+	if len(ranges) == 0 {
+		return offered
+	}
+
+	formats := make([]string, 0, len(offered))
+	quality := make(map[string]float64, len(offered))
+	for _, offer := range offered {
+		if q := qualityOf(ranges, offer); q > 0 {
+			formats = append(formats, offer)
+			quality[offer] = q
+		}
+	}
+	sort.SliceStable(formats, func(i, j int) bool {
+		return quality[formats[i]] > quality[formats[j]]
+	})
+	return formats
+}
+
+// SetAccepted sets Accept header data. It takes precedence over the Accept header of the request.
+func (c *Context) SetAccepted(formats ...string) {
+	c.Accepted = formats
+}
+
+// Negotiate calls different Render according to acceptable Accept format.
+// The data for a format is its format-specific field, or Data when that is nil. When the data
+// can not be encoded in the negotiated format (e.g. a map as XML) or there is no data for it,
+// the next acceptable format is tried. It answers 406 Not Acceptable when no offered format is
+// both accepted by the client and able to encode its data.
+func (c *Context) Negotiate(code int, config Negotiate) {
+	if len(config.Offered) > 0 {
+		for _, format := range c.acceptableFormats(config.Offered) {
+			contentType, body, ok := c.negotiateRender(format, config)
+			if !ok {
+				continue
+			}
+			c.SetHeader("Content-Type", contentType)
+			c.Status(code)
+			c.Writer.Write(body)
+			return
+		}
+	}
+	c.Abort()
+	c.String(http.StatusNotAcceptable, "406 NOT ACCEPTABLE: %s\n", strings.Join(config.Offered, ", "))
+}
+
+// negotiateRender 将数据编码为指定格式，没有数据或无法编码时返回false
+func (c *Context) negotiateRender(format string, config Negotiate) (contentType string, body []byte, ok bool) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case MIMEJSON:
+		data := chooseData(config.JSONData, config.Data)
+		if data == nil {
+			return "", nil, false
+		}
+		contentType, err = "application/json", json.NewEncoder(&buf).Encode(data)
+
+	case MIMEHTML:
+		data := chooseData(config.HTMLData, config.Data)
+		if data == nil || c.engine == nil {
+			return "", nil, false
+		}
+		contentType, err = "text/html; charset=utf-8", c.engine.executeHTML(&buf, config.HTMLName, data)
+
+	case MIMEXML, MIMEXML2:
+		data := chooseData(config.XMLData, config.Data)
+		if data == nil {
+			return "", nil, false
+		}
+		contentType, err = "application/xml; charset=utf-8", xml.NewEncoder(&buf).Encode(data)
+
+	case MIMEYAML, MIMEYAML2:
+		data := chooseData(config.YAMLData, config.Data)
+		if data == nil {
+			return "", nil, false
+		}
+		var out []byte
+		if out, err = yaml.Marshal(data); err == nil {
+			buf.Write(out)
+		}
+		contentType = "application/x-yaml; charset=utf-8"
+
+	case MIMEPlain:
+		data := chooseData(config.PlainData, config.Data)
+		if data == nil {
+			return "", nil, false
+		}
+		contentType = "text/plain"
+		fmt.Fprintf(&buf, "%v", data)
+
+	default:
+		return "", nil, false
+	}
+	if err != nil {
+		return "", nil, false
+	}
+	return contentType, buf.Bytes(), true
+}
+
+// chooseData 优先使用格式对应的数据，没有时使用通用的 Data
+func chooseData(custom, wildcard interface{}) interface{} {
+	if custom != nil {
+		return custom
+	}
+	return wildcard
+}
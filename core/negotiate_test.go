@@ -0,0 +1,135 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestNegotiateContext(accept string) (*Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return NewContext(w, req), w
+}
+
+// TestNegotiateFormat 测试Accept头的q值与通配符解析
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept  string
+		offered []string
+		want    string
+	}{
+		{"", []string{MIMEJSON, MIMEXML}, MIMEJSON},
+		{"application/xml", []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"text/html;q=0.8, application/json;q=0.9", []string{MIMEHTML, MIMEJSON}, MIMEJSON},
+		{"text/*", []string{MIMEJSON, MIMEPlain}, MIMEPlain},
+		{"*/*;q=0.1, application/json;q=0", []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"*/*", []string{MIMEYAML, MIMEJSON}, MIMEYAML},
+		{"image/png", []string{MIMEJSON, MIMEXML}, ""},
+		{"TEXT/HTML; charset=utf-8", []string{MIMEJSON, MIMEHTML}, MIMEHTML},
+		{"application/json;q=abc", []string{MIMEJSON}, ""},
+	}
+	for _, tc := range cases {
+		c, _ := newTestNegotiateContext(tc.accept)
+		if got := c.NegotiateFormat(tc.offered...); got != tc.want {
+			t.Fatalf("Accept %q: 协商结果应该为 %q, 实际为 %q", tc.accept, tc.want, got)
+		}
+	}
+}
+
+// TestSetAccepted 测试SetAccepted覆盖请求的Accept头
+func TestSetAccepted(t *testing.T) {
+	c, _ := newTestNegotiateContext("application/json")
+	c.SetAccepted(MIMEXML, MIMEJSON)
+	if got := c.NegotiateFormat(MIMEJSON, MIMEXML); got != MIMEJSON {
+		t.Fatalf("协商结果应该为 %q, 实际为 %q", MIMEJSON, got)
+	}
+	c.SetAccepted(MIMEXML)
+	if got := c.NegotiateFormat(MIMEJSON, MIMEXML); got != MIMEXML {
+		t.Fatalf("协商结果应该为 %q, 实际为 %q", MIMEXML, got)
+	}
+}
+
+// TestNegotiate 测试根据协商结果渲染响应
+func TestNegotiate(t *testing.T) {
+	c, w := newTestNegotiateContext("application/x-yaml")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEJSON, MIMEYAML},
+		Data:    H{"foo": "bar"},
+	})
+	if w.Code != http.StatusOK || w.Body.String() != "foo: bar\n" {
+		t.Fatalf("YAML响应不正确: %d %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-yaml; charset=utf-8" {
+		t.Fatalf("Content-Type不正确: %q", ct)
+	}
+
+	c, w = newTestNegotiateContext("application/json")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered:  []string{MIMEXML, MIMEJSON},
+		JSONData: H{"foo": "json"},
+		Data:     H{"foo": "bar"},
+	})
+	if w.Body.String() != "{\"foo\":\"json\"}\n" {
+		t.Fatalf("JSON响应不正确: %q", w.Body.String())
+	}
+
+	c, w = newTestNegotiateContext("image/png")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEJSON, MIMEXML},
+		Data:    H{"foo": "bar"},
+	})
+	if w.Code != http.StatusNotAcceptable || !c.IsAborted() {
+		t.Fatalf("无法协商时应该返回406, 实际为 %d", w.Code)
+	}
+}
+
+// TestNegotiateFallback 测试无法编码或没有数据的格式会被跳过，全部不可用时返回406而不是panic
+func TestNegotiateFallback(t *testing.T) {
+	// encoding/xml 无法编码map，回退到客户端可以接受的下一个格式
+	c, w := newTestNegotiateContext("application/xml, application/json;q=0.5")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEXML, MIMEJSON},
+		Data:    H{"foo": "bar"},
+	})
+	if w.Code != http.StatusOK || w.Body.String() != "{\"foo\":\"bar\"}\n" || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("XML无法编码时应该回退到JSON: %d %q", w.Code, w.Body.String())
+	}
+
+	c, w = newTestNegotiateContext("application/xml")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered: []string{MIMEXML, MIMEJSON},
+		Data:    H{"foo": "bar"},
+	})
+	if w.Code != http.StatusNotAcceptable || !c.IsAborted() {
+		t.Fatalf("没有可以编码数据的格式时应该返回406, 实际为 %d", w.Code)
+	}
+
+	c, w = newTestNegotiateContext("text/plain")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered:   []string{MIMEJSON, MIMEPlain},
+		PlainData: "plain",
+		Data:      H{"foo": "bar"},
+	})
+	if w.Code != http.StatusOK || w.Body.String() != "plain" {
+		t.Fatalf("纯文本应该使用 PlainData: %d %q", w.Code, w.Body.String())
+	}
+
+	c, w = newTestNegotiateContext("application/xml, application/json;q=0.5")
+	c.Negotiate(http.StatusOK, Negotiate{
+		Offered:  []string{MIMEXML, MIMEJSON},
+		JSONData: H{"foo": "json"},
+	})
+	if w.Body.String() != "{\"foo\":\"json\"}\n" {
+		t.Fatalf("没有数据的格式应该被跳过: %q", w.Body.String())
+	}
+
+	c, w = newTestNegotiateContext("application/json")
+	c.Negotiate(http.StatusOK, Negotiate{})
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("空的协商配置应该返回406, 实际为 %d", w.Code)
+	}
+}
@@ -1,36 +1,128 @@
 package core
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"runtime"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
 	"strings"
+	"syscall"
+	"vgo/log"
 )
 
+// RecoveryFunc defines the function passable to CustomRecovery.
+type RecoveryFunc func(c *Context, recovered interface{})
+
+// Recovery returns a middleware that recovers from any panics, logs them through vgo/log
+// and writes a 500 if the response was not written yet.
 func Recovery() HandlerFunc {
+	return RecoveryWithWriter(nil)
+}
+
+// CustomRecovery returns a middleware that recovers from any panics and calls the provided handle func to handle it.
+func CustomRecovery(handle RecoveryFunc) HandlerFunc {
+	return CustomRecoveryWithWriter(nil, handle)
+}
+
+// RecoveryWithWriter returns a middleware for a given writer that recovers from any panics and writes a 500 if there was one.
+// A nil writer logs through vgo/log.
+func RecoveryWithWriter(out io.Writer, recovery ...RecoveryFunc) HandlerFunc {
+	if len(recovery) > 0 {
+		return CustomRecoveryWithWriter(out, recovery[0])
+	}
+	return CustomRecoveryWithWriter(out, defaultHandleRecovery)
+}
+
+// CustomRecoveryWithWriter returns a middleware for a given writer that recovers from any panics and calls the provided handle func to handle it.
+//
+// The panic is recorded in c.Errors as a private error whose Meta is the Stack of the panic.
+// When the client connection is already broken (broken pipe or connection reset) no response
+// is written, neither is it when the handler already wrote the response; handle is only called
+// when a response can still be sent.
+func CustomRecoveryWithWriter(out io.Writer, handle RecoveryFunc) HandlerFunc {
 	return func(c *Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				message := fmt.Sprintf("%s", err)
-				log.Printf("%s\n\n", trace(message))
-				c.Fail()
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// http.ErrAbortHandler 表示主动中止响应，继续交给 net/http 处理，不记录也不写入错误响应
+			if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(recovered)
+			}
+			err, ok := recovered.(error)
+			if !ok {
+				err = fmt.Errorf("%v", recovered)
 			}
+			// 跳过当前函数与 runtime.gopanic，从发生panic的函数开始记录
+			message, stack := trace(err.Error(), 2)
+			c.Error(err).SetType(ErrorTypePrivate).SetMeta(stack)
+
+			headers := dumpRequestHeaders(c.Req)
+			if isBrokenPipe(err) {
+				writeRecovery(out, fmt.Sprintf("[Recovery] connection broken: %s\n%s", err, headers))
+				c.Abort()
+				return
+			}
+			writeRecovery(out, fmt.Sprintf("[Recovery] panic recovered:\n%s\n%s\n", headers, message))
+
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			handle(c, recovered)
 		}()
 		c.Next()
 	}
 }
 
-// print stack trace for debug
-func trace(message string) string {
-	var pcs [32]uintptr
-	n := runtime.Callers(3, pcs[:]) // 返回调用栈的程序计数器  skip first 3 caller
+// defaultHandleRecovery 默认返回500
+func defaultHandleRecovery(c *Context, _ interface{}) {
+	c.Abort()
+	c.Fail()
+}
+
+// writeRecovery out为nil时通过vgo/log记录
+func writeRecovery(out io.Writer, message string) {
+	if out == nil {
+		log.Error(message)
+		return
+	}
+	_, _ = io.WriteString(out, message+"\n")
+}
+
+// isBrokenPipe 判断是否为客户端断开连接导致的错误，此时无法再写入响应
+func isBrokenPipe(err error) bool {
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var se *os.SyscallError
+	if errors.As(err, &se) {
+		message := strings.ToLower(se.Error())
+		return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+	}
+	return false
+}
 
-	var str strings.Builder
-	str.WriteString(message + "\nTraceback:")
-	for _, pc := range pcs[:n] {
-		fn := runtime.FuncForPC(pc)   // 获取对应的函数
-		file, line := fn.FileLine(pc) // 获取调用函数的文件名和行号
-		str.WriteString(fmt.Sprintf("\n\t%s:%d", file, line))
+// dumpRequestHeaders 导出请求行与请求头，Authorization 的值会被隐藏
+func dumpRequestHeaders(req *http.Request) string {
+	if req == nil {
+		return ""
 	}
-	return str.String()
+	dump, _ := httputil.DumpRequest(req, false)
+	lines := strings.Split(strings.TrimSpace(string(dump)), "\r\n")
+	for i, line := range lines {
+		if name, _, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Authorization") {
+			lines[i] = name + ": *"
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// trace 返回错误信息与结构化调用栈的文本形式，skip 0 为调用 trace 的函数
+func trace(message string, skip int) (string, Stack) {
+	stack := CaptureStack(skip + 1)
+	return message + "\nTraceback: " + stack.String(), stack
 }
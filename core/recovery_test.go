@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestRecoveryWithWriter 测试panic被恢复、记录到 c.Errors，且日志中隐藏 Authorization
+func TestRecoveryWithWriter(t *testing.T) {
+	var out bytes.Buffer
+	var errs []string
+	r := New(Bare())
+	r.Use(func(c *Context) {
+		c.Next()
+		errs = c.Errors.Errors()
+	}, RecoveryWithWriter(&out))
+	r.GET("/panic", func(c *Context) {
+		panic("something wrong")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("X-Request-Id", "42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error" {
+		t.Fatalf("panic后应该返回500: %d %q", w.Code, w.Body.String())
+	}
+	if len(errs) != 1 || errs[0] != "something wrong" {
+		t.Fatalf("panic应该记录到 c.Errors: %v", errs)
+	}
+	log := out.String()
+	if strings.Contains(log, "secret-token") || !strings.Contains(log, "Authorization: *") || !strings.Contains(log, "X-Request-Id: 42") {
+		t.Fatalf("请求头导出不正确: %s", log)
+	}
+	if !strings.Contains(log, "recovery_test.go") {
+		t.Fatalf("日志中应该包含调用栈: %s", log)
+	}
+}
+
+// TestCustomRecovery 测试自定义恢复处理以及已经写入响应时不再写入
+func TestCustomRecovery(t *testing.T) {
+	var out bytes.Buffer
+	called := 0
+	r := New(WithRecovery(CustomRecoveryWithWriter(&out, func(c *Context, recovered interface{}) {
+		called++
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, H{"panic": fmt.Sprint(recovered)})
+	})))
+	r.GET("/panic", func(c *Context) {
+		panic(errors.New("boom"))
+	})
+	r.GET("/written", func(c *Context) {
+		c.String(http.StatusOK, "partial")
+		panic("after write")
+	})
+
+	w := performRequest(r, "GET", "/panic")
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != "{\"panic\":\"boom\"}\n" {
+		t.Fatalf("应该使用自定义的恢复处理: %d %q", w.Code, w.Body.String())
+	}
+	w = performRequest(r, "GET", "/written")
+	if w.Code != http.StatusOK || w.Body.String() != "partial" || called != 1 {
+		t.Fatalf("已经写入响应时不应该再写入: %d %q", w.Code, w.Body.String())
+	}
+}
+
+// TestRecoveryBrokenPipe 测试客户端断开连接时不写入响应
+func TestRecoveryBrokenPipe(t *testing.T) {
+	for _, errno := range []syscall.Errno{syscall.EPIPE, syscall.ECONNRESET} {
+		var out bytes.Buffer
+		r := New(WithRecovery(RecoveryWithWriter(&out)))
+		r.GET("/", func(c *Context) {
+			panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", errno)})
+		})
+		w := performRequest(r, "GET", "/")
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Fatalf("连接断开时不应该写入响应: %d %q", w.Code, w.Body.String())
+		}
+		if !strings.Contains(out.String(), "connection broken") || strings.Contains(out.String(), "Traceback") {
+			t.Fatalf("连接断开只需要记录错误: %s", out.String())
+		}
+	}
+}
+
+// TestRecoveryAbortHandler 测试 http.ErrAbortHandler 不会被恢复，而是继续向上panic
+func TestRecoveryAbortHandler(t *testing.T) {
+	var out bytes.Buffer
+	r := New(WithRecovery(RecoveryWithWriter(&out)))
+	r.GET("/", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Fatalf("ErrAbortHandler 应该继续panic: %v", recovered)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("ErrAbortHandler 不应该被记录: %s", out.String())
+		}
+	}()
+	performRequest(r, "GET", "/")
+}
+
+// TestNewBare 测试Bare模式下不注册默认中间件
+func TestNewBare(t *testing.T) {
+	if len(New().middlewares) != 1 || len(New(Bare()).middlewares) != 0 {
+		t.Fatal("默认只注册Recovery，Bare模式不注册中间件")
+	}
+	r := New(Bare())
+	r.GET("/", func(c *Context) {
+		panic("no recovery")
+	})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Bare模式下panic不应该被恢复")
+		}
+	}()
+	performRequest(r, "GET", "/")
+}
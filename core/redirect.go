@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redirect returns an HTTP redirect to the specific location.
+// The code must be a 3xx redirection status (or 201 Created), otherwise Redirect panics.
+// Use SafeRedirect when the location comes from user input.
+func (c *Context) Redirect(code int, location string) {
+	if (code < http.StatusMultipleChoices || code > http.StatusPermanentRedirect) && code != http.StatusCreated {
+		panic(fmt.Sprintf("Cannot redirect with status code %d", code))
+	}
+	c.StatusCode = code
+	http.Redirect(c.Writer, c.Req, location, code)
+}
+
+// SafeRedirect works like Redirect, but guards against open redirects: only relative locations,
+// the host of the current request and the hosts in Engine.RedirectAllowedHosts are accepted.
+// ErrUnsafeRedirect is returned and nothing is written for any other location.
+func (c *Context) SafeRedirect(code int, location string) error {
+	if !c.isSafeRedirect(location) {
+		return ErrUnsafeRedirect
+	}
+	c.Redirect(code, location)
+	return nil
+}
+
+// RedirectToRoute redirects to the route registered with the given name, e.g.
+//
+//	r.GET("/user/:id", getUser).Name("user")
+//	c.RedirectToRoute(http.StatusFound, "user", map[string]string{"id": "1"})
+//
+// It panics if the route is not defined or a param is missing.
+func (c *Context) RedirectToRoute(code int, name string, params map[string]string) {
+	location, err := c.namedRoute(name).URL(params)
+	if err != nil {
+		panic(err)
+	}
+	c.Redirect(code, location)
+}
+
+// ForwardToRoute re-dispatches the request to the route registered with the given name inside
+// the same request, without a round trip to the client. The group middleware of that route (auth,
+// CSRF and so on) and its handler are executed with the current context; the global middleware has
+// already run and is not repeated. The rest of the current chain is aborted.
+// Like Abort, it does not stop the calling handler, which should return right after.
+// It panics if the route is not defined or a param is missing.
+func (c *Context) ForwardToRoute(name string, params map[string]string) {
+	ri := c.namedRoute(name)
+	rawPath, err := ri.URL(params)
+	if err != nil {
+		panic(err)
+	}
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		panic(err)
+	}
+	c.Req.Method = ri.Method
+	c.Req.URL.Path = path
+	c.Req.URL.RawPath = rawPath
+	c.engine.HandleContext(c)
+}
+
+// namedRoute 查找命名路由，context没有绑定engine或路由不存在时panic
+func (c *Context) namedRoute(name string) *RouteInfo {
+	if c.engine == nil {
+		panic(fmt.Sprintf("route %q is not defined: context is not bound to an engine", name))
+	}
+	ri, ok := c.engine.router.getNamedRoute(name)
+	if !ok {
+		panic(fmt.Sprintf("route %q is not defined", name))
+	}
+	return ri
+}
+
+// HandleContext re-enters a context that has been rewritten, e.g. after changing c.Req.URL.Path.
+// The route is matched again against c.Req and its chain is executed: the middleware of the groups
+// the new path belongs to, then the route handler. The global middleware registered on the engine
+// has already run for the current request and is not repeated. Keys set so far are kept, and the
+// rest of the current chain is aborted once the new chain returns.
+func (engine *Engine) HandleContext(c *Context) {
+	c.Path = c.Req.URL.Path
+	c.Method = c.Req.Method
+	c.Params = nil
+	c.fullPath = ""
+	c.queryCache = nil
+	c.index = -1
+	c.Handlers = engine.forwardMiddlewares(c.Path)
+
+	engine.router.handle(c)
+	c.Abort()
+}
+
+// forwardMiddlewares 收集转发目标路径所属分组的中间件，引擎的全局中间件在当前请求中已经执行过，不再重复执行
+func (engine *Engine) forwardMiddlewares(path string) []HandlerFunc {
+	var middlewares []HandlerFunc
+	for _, group := range engine.groups {
+		if group != engine.GroupRouter && strings.HasPrefix(path, group.prefix) {
+			middlewares = append(middlewares, group.middlewares...)
+		}
+	}
+	return middlewares
+}
+
+// isSafeRedirect 判断跳转地址是否为相对地址、当前请求的host或白名单中的host
+func (c *Context) isSafeRedirect(location string) bool {
+	// 拒绝控制字符与反斜杠，浏览器会把 /\evil.com 当作 //evil.com 处理
+	for i := 0; i < len(location); i++ {
+		if location[i] < 0x20 || location[i] == 0x7F || location[i] == '\\' {
+			return false
+		}
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" && u.User == nil {
+		// 协议相对地址 //evil.com 会被解析为host
+		return !strings.HasPrefix(location, "//")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	host := u.Hostname()
+	if reqHost := c.Host(); reqHost != "" && strings.EqualFold(host, hostWithoutPort(reqHost)) {
+		return true
+	}
+	if c.engine != nil {
+		for _, allowed := range c.engine.RedirectAllowedHosts {
+			if strings.EqualFold(host, allowed) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostWithoutPort 去掉host中的端口
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
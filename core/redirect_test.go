@@ -0,0 +1,158 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestContextRedirect 测试重定向状态码校验
+func TestContextRedirect(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.Redirect(http.StatusFound, "/login")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/login" {
+		t.Fatalf("重定向响应不正确: %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("非3xx状态码应该panic")
+		}
+	}()
+	c.Redirect(http.StatusOK, "/login")
+}
+
+// TestContextSafeRedirect 测试开放重定向防护
+func TestContextSafeRedirect(t *testing.T) {
+	r := New()
+	r.RedirectAllowedHosts = []string{"sso.vgo.dev"}
+	cases := []struct {
+		location string
+		safe     bool
+	}{
+		{"/list.html", true},
+		{"list.html?page=2", true},
+		{"http://example.com/list.html", true},
+		{"https://SSO.vgo.dev/callback", true},
+		{"https://evil.com", false},
+		{"//evil.com", false},
+		{"/\\evil.com", false},
+		{"javascript:alert(1)", false},
+		{"https:evil.com", false},
+		{"/list\r\nSet-Cookie: a=b", false},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+		c.engine = r
+		err := c.SafeRedirect(http.StatusFound, tc.location)
+		if tc.safe && (err != nil || w.Code != http.StatusFound) {
+			t.Fatalf("%q 应该允许跳转: %v", tc.location, err)
+		}
+		if !tc.safe && (err != ErrUnsafeRedirect || w.Header().Get("Location") != "") {
+			t.Fatalf("%q 不应该允许跳转", tc.location)
+		}
+	}
+}
+
+// TestContextRedirectToRoute 测试命名路由的重定向与内部转发
+func TestContextRedirectToRoute(t *testing.T) {
+	r := New()
+	middlewareCalls := 0
+	r.Use(func(c *Context) {
+		middlewareCalls++
+		c.Next()
+	})
+	r.GET("/user/:id", func(c *Context) {
+		c.String(http.StatusOK, "user %s %s", c.Param("id"), c.GetString("from"))
+	}).Name("user")
+	v1 := r.Group("/v1")
+	v1.Use(func(c *Context) {
+		c.Set("group", "v1")
+		c.Next()
+	})
+	v1.GET("/files/*filepath", func(c *Context) {
+		c.String(http.StatusOK, "file %s %s", c.Param("filepath"), c.GetString("group"))
+	}).Name("file")
+	admin := r.Group("/admin")
+	admin.Use(func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	admin.GET("/secret", func(c *Context) {
+		c.String(http.StatusOK, "secret")
+	}).Name("secret")
+	r.GET("/open", func(c *Context) {
+		c.ForwardToRoute("secret", nil)
+	})
+	r.GET("/old/:id", func(c *Context) {
+		c.RedirectToRoute(http.StatusMovedPermanently, "user", map[string]string{"id": c.Param("id")})
+	})
+	r.POST("/login", func(c *Context) {
+		c.Set("from", "login")
+		c.ForwardToRoute("user", map[string]string{"id": "7"})
+	})
+	r.GET("/profile", func(c *Context) {
+		c.ForwardToRoute("user", map[string]string{"id": "a b"})
+	})
+	r.GET("/download", func(c *Context) {
+		c.ForwardToRoute("file", map[string]string{"filepath": "css/app.css"})
+	})
+
+	if url, err := r.URL("user", map[string]string{"id": "a b"}); err != nil || url != "/user/a%20b" {
+		t.Fatalf("URL 生成结果不正确: %q %v", url, err)
+	}
+	if _, err := r.URL("user", nil); err == nil {
+		t.Fatal("缺少参数时 URL 应该返回错误")
+	}
+
+	w := performRequest(r, "GET", "/old/1")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/user/1" {
+		t.Fatalf("命名路由重定向不正确: %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	middlewareCalls = 0
+	w = performRequest(r, "POST", "/login")
+	if w.Code != http.StatusOK || w.Body.String() != "user 7 login" {
+		t.Fatalf("内部转发结果不正确: %d %q", w.Code, w.Body.String())
+	}
+	if middlewareCalls != 1 {
+		t.Fatalf("内部转发不应该重复执行中间件: %d", middlewareCalls)
+	}
+
+	w = performRequest(r, "GET", "/profile")
+	if w.Body.String() != "user a b " {
+		t.Fatalf("内部转发的路径参数应该是解码后的值: %q", w.Body.String())
+	}
+
+	w = performRequest(r, "GET", "/open")
+	if w.Code != http.StatusUnauthorized || w.Body.String() == "secret" {
+		t.Fatalf("转发到受保护的路由时应该经过其分组中间件: %d %q", w.Code, w.Body.String())
+	}
+
+	middlewareCalls = 0
+	w = performRequest(r, "GET", "/download")
+	if w.Body.String() != "file css/app.css v1" {
+		t.Fatalf("内部转发应该执行目标路由的分组中间件: %q", w.Body.String())
+	}
+	if middlewareCalls != 1 {
+		t.Fatalf("内部转发不应该重复执行全局中间件: %d", middlewareCalls)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("没有绑定engine的context重定向到命名路由应该panic")
+			}
+		}()
+		c := NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		c.RedirectToRoute(http.StatusFound, "user", map[string]string{"id": "1"})
+	}()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("重复的路由名称应该panic")
+		}
+	}()
+	r.GET("/user2/:id", nil).Name("user")
+}
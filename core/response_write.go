@@ -2,6 +2,7 @@ package core
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"net"
 	"net/http"
@@ -47,11 +48,11 @@ type responseWriter struct {
 
 var _ ResponseWriter = &responseWriter{}
 
-//func (r *responseWriter) reset(writer http.ResponseWriter) {
-//	r.ResponseWriter = writer
-//	r.size = noWritten
-//	r.status = defaultStatus
-//}
+func (r *responseWriter) reset(writer http.ResponseWriter) {
+	r.ResponseWriter = writer
+	r.size = noWritten
+	r.status = defaultStatus
+}
 
 func (r *responseWriter) WriteHeader(code int) {
 	if code > 0 && r.status != code {
@@ -97,10 +98,16 @@ func (r *responseWriter) Written() bool {
 
 // Hijack implements the http.Hijacker interface.
 func (r *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if r.size < 0 {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the ResponseWriter doesn't support the Hijacker interface")
+	}
+	conn, rw, err := hijacker.Hijack()
+	// 只有接管成功后才视为已写入，失败时仍然可以正常返回错误响应
+	if err == nil && r.size < 0 {
 		r.size = 0
 	}
-	return r.ResponseWriter.(http.Hijacker).Hijack()
+	return conn, rw, err
 }
 
 // CloseNotify implements the http.CloseNotify interface.
@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"vgo/utils"
 )
@@ -14,12 +15,20 @@ import (
 */
 
 // Router
-//	- roots key eg, roots['GET'], roots['POST']
-//  - handlers key eg, handlers['GET-/p/:lang/doc'], handlers['POST-/p/book']
+//   - roots key eg, roots['GET'], roots['POST']
+//   - handlers key eg, handlers['GET-/p/:lang/doc'], handlers['POST-/p/book']
 type Router struct {
 	roots    map[string]*node       // 存储每种请求方式的Trie树根节点
 	handlers map[string]HandlerFunc // 存储每种请求方式的HandlerFunc
 	table    *utils.Set             // 存储所有注册过的路由
+	names    map[string]*RouteInfo  // 存储命名路由，key为路由名称
+}
+
+// RouteInfo 注册路由的返回值，可以通过 Name 为路由命名，用于反向生成URL
+type RouteInfo struct {
+	Method  string
+	Pattern string
+	router  *Router
 }
 
 // newTrieRouter 前缀树路由构造函数
@@ -28,6 +37,7 @@ func newRouter() *Router {
 		roots:    make(map[string]*node),
 		handlers: make(map[string]HandlerFunc),
 		table:    utils.NewSet(),
+		names:    make(map[string]*RouteInfo),
 	}
 }
 
@@ -48,7 +58,7 @@ func parsePattern(pattern string) []string {
 }
 
 // addRoute 注册路由
-func (r *Router) addRoute(method string, pattern string, handler HandlerFunc) {
+func (r *Router) addRoute(method string, pattern string, handler HandlerFunc) *RouteInfo {
 	parts := parsePattern(pattern)
 
 	key := method + "-" + pattern
@@ -67,6 +77,43 @@ func (r *Router) addRoute(method string, pattern string, handler HandlerFunc) {
 
 	r.roots[method].insert(pattern, parts, 0)
 	r.handlers[key] = handler
+	return &RouteInfo{Method: method, Pattern: pattern, router: r}
+}
+
+// Name 为路由命名，名称重复时panic
+//
+//	r.GET("/user/:id", getUser).Name("user")
+func (ri *RouteInfo) Name(name string) *RouteInfo {
+	if _, ok := ri.router.names[name]; ok {
+		panic(fmt.Sprintf("route name conflict %s", name))
+	}
+	ri.router.names[name] = ri
+	return ri
+}
+
+// URL 使用参数填充路由中的 :name 与 *name 部分，生成请求路径
+func (ri *RouteInfo) URL(params map[string]string) (string, error) {
+	parts := parsePattern(ri.Pattern)
+	for i, part := range parts {
+		if part[0] != ':' && part[0] != '*' {
+			continue
+		}
+		value, ok := params[part[1:]]
+		if !ok && part[0] == ':' {
+			return "", fmt.Errorf("missing param %q for route %s", part[1:], ri.Pattern)
+		}
+		if part[0] == ':' {
+			value = url.PathEscape(value)
+		}
+		parts[i] = strings.Trim(value, "/")
+	}
+	return "/" + strings.Join(parts, "/"), nil
+}
+
+// getNamedRoute 根据名称查找路由
+func (r *Router) getNamedRoute(name string) (*RouteInfo, bool) {
+	ri, ok := r.names[name]
+	return ri, ok
 }
 
 // getRoute 路由匹配
@@ -105,6 +152,7 @@ func (r *Router) handle(c *Context) {
 		// 在调用匹配到的handler前，将解析出来的路由参数赋值给了c.Params，这样就能够在handler中，通过Context对象访问到具体的值了。
 		key := c.Method + "-" + n.pattern
 		c.Params = params
+		c.fullPath = n.pattern
 		c.Handlers = append(c.Handlers, r.handlers[key])
 	} else {
 		c.Handlers = append(c.Handlers, func(c *Context) {
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SSEvent 一条Server-Sent Event，字段含义见 https://html.spec.whatwg.org/multipage/server-sent-events.html
+type SSEvent struct {
+	// Id 事件id，客户端重连时会通过 Last-Event-ID 请求头带回最后收到的id
+	Id string
+	// Event 事件名称，为空时客户端按 message 事件处理
+	Event string
+	// Retry 建议客户端断线重连的间隔，单位毫秒，0表示不设置
+	Retry uint
+	// Data 事件数据，string与[]byte原样输出，其他类型编码为JSON
+	Data interface{}
+}
+
+// sseFieldReplacer id与event字段不能包含换行，否则会破坏事件格式
+var sseFieldReplacer = strings.NewReplacer("\n", "", "\r", "")
+
+// encode 按 text/event-stream 格式编码事件，多行数据拆分为多个 data 字段
+func (e SSEvent) encode(w io.Writer) error {
+	var buf bytes.Buffer
+	if e.Id != "" {
+		buf.WriteString("id:" + sseFieldReplacer.Replace(e.Id) + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event:" + sseFieldReplacer.Replace(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString(fmt.Sprintf("retry:%d\n", e.Retry))
+	}
+
+	var data string
+	switch v := e.Data.(type) {
+	case nil:
+	case string:
+		data = v
+	case []byte:
+		data = string(v)
+	default:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data = string(jsonBytes)
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data:" + line + "\n")
+	}
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// setSSEHeaders 设置事件流响应头，响应已经开始写入时不再修改
+func (c *Context) setSSEHeaders() {
+	if c.Writer.Written() {
+		return
+	}
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 关闭nginx的代理缓冲，否则事件会被攒起来一起发送
+	header.Set("X-Accel-Buffering", "no")
+}
+
+// SSEvent writes a Server-Sent Event into the body stream and flushes it to the client.
+func (c *Context) SSEvent(name string, message interface{}) {
+	_ = c.WriteSSE(SSEvent{Event: name, Data: message})
+}
+
+// WriteSSE writes a Server-Sent Event with id and retry hint into the body stream
+// and flushes it to the client.
+func (c *Context) WriteSSE(event SSEvent) error {
+	c.setSSEHeaders()
+	if err := event.encode(c.Writer); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// LastEventID returns the Last-Event-ID header sent by a reconnecting client,
+// so that the stream can be resumed after the last event it received.
+func (c *Context) LastEventID() string {
+	return c.GetHeader("Last-Event-ID")
+}
+
+// Stream sends a streaming response and returns a boolean
+// indicates "Is client disconnected in middle of stream".
+// step is called in a loop and the response is flushed after each call, until step returns false
+// or the request context is done because the client went away.
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	w := c.Writer
+	clientGone := c.Req.Context().Done()
+	for {
+		select {
+		case <-clientGone:
+			return true
+		default:
+			keepOpen := step(w)
+			w.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
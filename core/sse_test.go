@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestContextSSEvent 测试事件编码与响应头
+func TestContextSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest("GET", "/", nil))
+	c.SSEvent("log", "line1\nline2")
+	_ = c.WriteSSE(SSEvent{Id: "42\n", Event: "user", Retry: 3000, Data: H{"name": "vgo"}})
+	c.SSEvent("", nil)
+
+	want := "event:log\ndata:line1\ndata:line2\n\n" +
+		"id:42\nevent:user\nretry:3000\ndata:{\"name\":\"vgo\"}\n\n" +
+		"data:\n\n"
+	if w.Body.String() != want {
+		t.Fatalf("事件编码不正确: %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("事件流响应头不正确: %v", w.Header())
+	}
+	if !w.Flushed {
+		t.Fatal("事件应该立即刷新到客户端")
+	}
+}
+
+// TestContextStream 测试客户端断开时停止推送，并支持通过 Last-Event-ID 续传
+func TestContextStream(t *testing.T) {
+	r := New()
+	gone := make(chan bool, 1)
+	r.GET("/stream", func(c *Context) {
+		id := 0
+		if last := c.LastEventID(); last == "2" {
+			id = 2
+		}
+		gone <- c.Stream(func(w io.Writer) bool {
+			id++
+			c.WriteSSE(SSEvent{Id: string(rune('0' + id)), Data: "tick"})
+			time.Sleep(10 * time.Millisecond)
+			return true
+		})
+	})
+	r.GET("/finite", func(c *Context) {
+		n := 0
+		gone <- c.Stream(func(w io.Writer) bool {
+			n++
+			_, _ = w.Write([]byte("chunk"))
+			return n < 3
+		})
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/stream", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "id:3\n" {
+		t.Fatalf("应该从 Last-Event-ID 之后继续推送: %q %v", line, err)
+	}
+	resp.Body.Close()
+
+	select {
+	case disconnected := <-gone:
+		if !disconnected {
+			t.Fatal("客户端断开时 Stream 应该返回true")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("客户端断开后 Stream 应该停止")
+	}
+
+	resp, err = http.Get(srv.URL + "/finite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Count(string(body), "chunk") != 3 || <-gone {
+		t.Fatalf("step返回false时 Stream 应该正常结束: %q", body)
+	}
+}
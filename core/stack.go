@@ -0,0 +1,148 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxStackDepth 调用栈最多记录的帧数
+	maxStackDepth = 128
+	// stackSourceLines 源码片段中出错行前后各显示的行数
+	stackSourceLines = 2
+)
+
+// internalPackages 框架自身的包，第一个不属于这些包的帧会被高亮
+var internalPackages = []string{"runtime.", "vgo/core.", "vgo/core/websocket.", "vgo/log."}
+
+// SourceLine is one line of the source code around a stack frame.
+type SourceLine struct {
+	Line int    `json:"line"`
+	Code string `json:"code"`
+}
+
+// Frame is one entry of a captured stack.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	// Source holds the lines around Line when the source file is readable.
+	Source []SourceLine `json:"source,omitempty"`
+	// Highlight marks the first frame outside vgo and the Go runtime, usually the application code that panicked.
+	Highlight bool `json:"highlight,omitempty"`
+}
+
+// Stack is a structured stack trace, it can be serialised as JSON by log hooks.
+type Stack struct {
+	Goroutine uint64  `json:"goroutine"`
+	Frames    []Frame `json:"frames"`
+}
+
+// CaptureStack returns the stack of the calling goroutine, skip 0 being the caller of CaptureStack.
+func CaptureStack(skip int) Stack {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+2, pcs) // 跳过 runtime.Callers 与 CaptureStack 本身
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := Stack{Goroutine: goroutineID()}
+	highlighted := false
+	for {
+		frame, more := frames.Next()
+		f := Frame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+			Source:   sourceLines(frame.File, frame.Line),
+		}
+		if !highlighted && !isInternalFrame(frame) {
+			f.Highlight = true
+			highlighted = true
+		}
+		stack.Frames = append(stack.Frames, f)
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// String formats the stack like
+//
+//	goroutine 7:
+//	-> main.handler()
+//	       /app/main.go:12
+//	           11	func handler(c *core.Context) {
+//	         > 12		panic("boom")
+//	           13	}
+func (s Stack) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "goroutine %d:", s.Goroutine)
+	for _, f := range s.Frames {
+		marker := "   "
+		if f.Highlight {
+			marker = "-> "
+		}
+		fmt.Fprintf(&buf, "\n%s%s()\n\t%s:%d", marker, f.Function, f.File, f.Line)
+		for _, src := range f.Source {
+			current := "  "
+			if src.Line == f.Line {
+				current = "> "
+			}
+			fmt.Fprintf(&buf, "\n\t  %s%d\t%s", current, src.Line, src.Code)
+		}
+	}
+	return buf.String()
+}
+
+// isInternalFrame 判断帧是否属于框架或Go运行时，测试文件中的帧总是视为应用代码
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	for _, pkg := range internalPackages {
+		if strings.HasPrefix(frame.Function, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLines 读取出错行前后的源码，源码不可读时返回nil
+func sourceLines(file string, line int) []SourceLine {
+	data, err := os.ReadFile(file)
+	if err != nil || line <= 0 {
+		return nil
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	if line > len(lines) {
+		return nil
+	}
+	start, end := line-stackSourceLines, line+stackSourceLines
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	source := make([]SourceLine, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		source = append(source, SourceLine{Line: i, Code: strings.TrimRight(string(lines[i-1]), "\r")})
+	}
+	return source
+}
+
+// goroutineID 从 runtime.Stack 的第一行 "goroutine 7 [running]:" 中解析当前goroutine的ID
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
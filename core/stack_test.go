@@ -0,0 +1,63 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestCaptureStack 测试结构化调用栈包含函数名、源码片段并高亮第一个应用代码帧
+func TestCaptureStack(t *testing.T) {
+	stack := CaptureStack(0)
+	if stack.Goroutine == 0 || len(stack.Frames) < 2 {
+		t.Fatalf("调用栈不完整: %+v", stack)
+	}
+	first := stack.Frames[0]
+	if first.Function != "vgo/core.TestCaptureStack" || !strings.HasSuffix(first.File, "stack_test.go") || !first.Highlight {
+		t.Fatalf("第一帧应该是调用方并被高亮: %+v", first)
+	}
+	if len(first.Source) != 2*stackSourceLines+1 || !strings.Contains(first.Source[stackSourceLines].Code, "CaptureStack(0)") {
+		t.Fatalf("源码片段不正确: %+v", first.Source)
+	}
+	for _, f := range stack.Frames[1:] {
+		if f.Highlight {
+			t.Fatalf("只应该高亮一帧: %+v", f)
+		}
+	}
+
+	data, err := json.Marshal(stack)
+	if err != nil || !strings.Contains(string(data), `"function":"vgo/core.TestCaptureStack"`) {
+		t.Fatalf("调用栈应该可以序列化为JSON: %s %v", data, err)
+	}
+	if s := stack.String(); !strings.Contains(s, "-> vgo/core.TestCaptureStack()") || !strings.Contains(s, "> ") {
+		t.Fatalf("文本格式不正确: %s", s)
+	}
+}
+
+// TestRecoveryStack 测试panic的调用栈从发生panic的handler开始，并作为错误的Meta
+func TestRecoveryStack(t *testing.T) {
+	var stack Stack
+	r := New(Bare())
+	r.Use(func(c *Context) {
+		c.Next()
+		stack, _ = c.Errors.Last().Meta.(Stack)
+	}, RecoveryWithWriter(&strings.Builder{}))
+	r.GET("/", func(c *Context) {
+		var m map[string]int
+		m["nil map"] = 1
+	})
+	performRequest(r, "GET", "/")
+
+	var highlighted *Frame
+	for i := range stack.Frames {
+		if stack.Frames[i].Highlight {
+			highlighted = &stack.Frames[i]
+		}
+	}
+	if highlighted == nil || highlighted.Function != "vgo/core.TestRecoveryStack.func2" {
+		t.Fatalf("应该高亮发生panic的handler: %+v", stack.Frames)
+	}
+	if len(stack.Frames) > maxStackDepth {
+		t.Fatal("调用栈超出最大深度")
+	}
+}
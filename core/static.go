@@ -0,0 +1,149 @@
+package core
+
+import (
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// onlyFilesFS 关闭目录列表的文件系统：没有 index.html 的目录视为不存在
+type onlyFilesFS struct {
+	fs http.FileSystem
+}
+
+// Dir returns a http.FileSystem that can be used by http.FileServer(). It is used internally
+// in GroupRouter.Static().
+// if listDirectory == true, then it works the same as http.Dir() otherwise it returns
+// a filesystem that prevents http.FileServer() to list the directory files.
+func Dir(root string, listDirectory bool) http.FileSystem {
+	return wrapFileSystem(http.Dir(root), listDirectory)
+}
+
+// FS returns a http.FileSystem backed by fsys, for example an embed.FS, following the same
+// directory listing rule as Dir.
+func FS(fsys fs.FS, listDirectory bool) http.FileSystem {
+	return wrapFileSystem(http.FS(fsys), listDirectory)
+}
+
+func wrapFileSystem(fileSystem http.FileSystem, listDirectory bool) http.FileSystem {
+	if listDirectory {
+		return fileSystem
+	}
+	return &onlyFilesFS{fileSystem}
+}
+
+// Open conforms to http.FileSystem.
+func (o *onlyFilesFS) Open(name string) (http.File, error) {
+	f, err := o.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if stat.IsDir() {
+		index, err := o.fs.Open(strings.TrimSuffix(name, "/") + "/index.html")
+		if err != nil {
+			_ = f.Close()
+			return nil, os.ErrNotExist
+		}
+		_ = index.Close()
+	}
+	return f, nil
+}
+
+// containsDotDot 判断路径中是否包含 .. 路径段，用于阻止目录穿越
+func containsDotDot(p string) bool {
+	if !strings.Contains(p, "..") {
+		return false
+	}
+	for _, seg := range strings.FieldsFunc(p, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// StaticFile registers a single route in order to serve a single file of the local filesystem.
+// router.StaticFile("favicon.ico", "./resources/favicon.ico")
+func (group *GroupRouter) StaticFile(relativePath, filepath string) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static file")
+	}
+	handler := func(c *Context) {
+		c.File(filepath)
+	}
+	group.addRoute("GET", relativePath, handler)
+	group.addRoute("HEAD", relativePath, handler)
+}
+
+// Static serves files from the given file system root, with directory listing turned off.
+// Internally a http.FileServer is used, therefore http.NotFound is used instead
+// of the Router's NotFound handler.
+// To use the operating system's file system implementation,
+// use :
+//
+//	router.Static("/static", "/var/www")
+func (group *GroupRouter) Static(relativePath, root string) {
+	group.StaticFS(relativePath, Dir(root, false))
+}
+
+// StaticFS works just like `Static()` but a custom `http.FileSystem` can be used instead.
+// Use core.FS to serve an embed.FS, e.g. router.StaticFS("/static", core.FS(assets, false)).
+// The prefix itself is served as the root directory: "/static/" returns its index.html (or the
+// listing when enabled), and "/static" is redirected to "/static/".
+func (group *GroupRouter) StaticFS(relativePath string, fileSystem http.FileSystem) {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when serving a static folder")
+	}
+	handler := group.createStaticHandler(relativePath, fileSystem)
+	urlPattern := path.Join(relativePath, "/*filepath")
+
+	// Register GET and HEAD handlers
+	group.addRoute("GET", urlPattern, handler)
+	group.addRoute("HEAD", urlPattern, handler)
+
+	// *filepath 不匹配空路径，单独注册前缀本身，与目录一样没有结尾的 / 时重定向，保证页面中的相对地址正确
+	rootHandler := func(c *Context) {
+		if !strings.HasSuffix(c.Req.URL.Path, "/") {
+			location := c.Req.URL.Path + "/"
+			if c.Req.URL.RawQuery != "" {
+				location += "?" + c.Req.URL.RawQuery
+			}
+			c.Redirect(http.StatusMovedPermanently, location)
+			return
+		}
+		handler(c)
+	}
+	rootPattern := path.Join("/", relativePath)
+	group.addRoute("GET", rootPattern, rootHandler)
+	group.addRoute("HEAD", rootPattern, rootHandler)
+}
+
+// createStaticHandler 基于模糊匹配路由的 *filepath 参数创建静态文件handler
+func (group *GroupRouter) createStaticHandler(relativePath string, fileSystem http.FileSystem) HandlerFunc {
+	absolutePath := path.Join(group.prefix, relativePath)
+	fileServer := http.StripPrefix(absolutePath, http.FileServer(fileSystem))
+
+	return func(c *Context) {
+		file := c.Param("filepath")
+		if containsDotDot(file) || containsDotDot(c.Req.URL.Path) {
+			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+			return
+		}
+		// Check if file exists and/or if we have permission to access it
+		f, err := fileSystem.Open("/" + file)
+		if err != nil {
+			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
+			return
+		}
+		_ = f.Close()
+
+		fileServer.ServeHTTP(c.Writer, c.Req)
+	}
+}
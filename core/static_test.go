@@ -0,0 +1,112 @@
+package core
+
+import (
+	"net/http"
+	"testing"
+	"testing/fstest"
+)
+
+// TestStatic 测试静态文件服务
+func TestStatic(t *testing.T) {
+	r := New()
+	r.Static("/static", "testdata/static")
+	r.StaticFile("/favicon.txt", "testdata/static/hello.txt")
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/static/hello.txt", http.StatusOK, "hello vgo\n"},
+		{"GET", "/static/css/app.css", http.StatusOK, "body{}\n"},
+		{"HEAD", "/static/hello.txt", http.StatusOK, ""},
+		{"GET", "/static/docs/", http.StatusOK, "<h1>docs</h1>\n"},
+		{"GET", "/static/missing.txt", http.StatusNotFound, ""},
+		{"GET", "/favicon.txt", http.StatusOK, "hello vgo\n"},
+	}
+	for _, tc := range cases {
+		w := performRequest(r, tc.method, tc.path)
+		if w.Code != tc.code {
+			t.Fatalf("%s %s: 状态码应该为 %d, 实际为 %d", tc.method, tc.path, tc.code, w.Code)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Fatalf("%s %s: 响应体不正确: %q", tc.method, tc.path, w.Body.String())
+		}
+	}
+}
+
+// TestStaticListDirectory 测试默认关闭目录列表
+func TestStaticListDirectory(t *testing.T) {
+	r := New()
+	r.Static("/static", "testdata/static")
+	if w := performRequest(r, "GET", "/static/empty/"); w.Code != http.StatusNotFound {
+		t.Fatalf("默认不应该列出目录, 实际状态码为 %d", w.Code)
+	}
+
+	r = New()
+	r.StaticFS("/static", Dir("testdata/static", true))
+	if w := performRequest(r, "GET", "/static/empty/"); w.Code != http.StatusOK {
+		t.Fatalf("开启目录列表后应该返回200, 实际状态码为 %d", w.Code)
+	}
+}
+
+// TestStaticRoot 测试访问静态前缀本身
+func TestStaticRoot(t *testing.T) {
+	r := New()
+	g := r.Group("/v1")
+	g.Static("/docs", "testdata/static/docs")
+	if w := performRequest(r, "GET", "/v1/docs/"); w.Code != http.StatusOK || w.Body.String() != "<h1>docs</h1>\n" {
+		t.Fatalf("前缀根目录应该返回index.html, 实际为 %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(r, "HEAD", "/v1/docs/"); w.Code != http.StatusOK {
+		t.Fatalf("HEAD 前缀根目录应该返回200, 实际状态码为 %d", w.Code)
+	}
+	w := performRequest(r, "GET", "/v1/docs?v=1")
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/v1/docs/?v=1" {
+		t.Fatalf("没有结尾的 / 时应该重定向, 实际为 %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	r = New()
+	r.Static("/static", "testdata/static")
+	if w := performRequest(r, "GET", "/static/"); w.Code != http.StatusNotFound {
+		t.Fatalf("没有index.html且未开启目录列表时应该返回404, 实际状态码为 %d", w.Code)
+	}
+
+	r = New()
+	r.StaticFS("/static", Dir("testdata/static", true))
+	if w := performRequest(r, "GET", "/static/"); w.Code != http.StatusOK {
+		t.Fatalf("开启目录列表后前缀根目录应该返回200, 实际状态码为 %d", w.Code)
+	}
+}
+
+// TestStaticPathTraversal 测试阻止目录穿越
+func TestStaticPathTraversal(t *testing.T) {
+	r := New()
+	g := r.Group("/v1")
+	g.Static("/static", "testdata/static")
+	for _, p := range []string{"/v1/static/../static_test.go", "/v1/static/css/../../html_test.go", "/v1/static/..%2fstatic_test.go"} {
+		if w := performRequest(r, "GET", p); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: 目录穿越应该返回404, 实际状态码为 %d", p, w.Code)
+		}
+	}
+	if w := performRequest(r, "GET", "/v1/static/hello.txt"); w.Code != http.StatusOK {
+		t.Fatalf("分组下的静态文件应该返回200, 实际状态码为 %d", w.Code)
+	}
+}
+
+// TestStaticEmbedFS 测试基于fs.FS（如embed.FS）的静态文件服务
+func TestStaticEmbedFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":       {Data: []byte("console.log('vgo')")},
+		"img/logo.svg": {Data: []byte("<svg/>")},
+	}
+	r := New()
+	r.StaticFS("/assets", FS(fsys, false))
+	if w := performRequest(r, "GET", "/assets/app.js"); w.Code != http.StatusOK || w.Body.String() != "console.log('vgo')" {
+		t.Fatalf("FS静态文件响应不正确: %d %q", w.Code, w.Body.String())
+	}
+	if w := performRequest(r, "GET", "/assets/img/"); w.Code != http.StatusNotFound {
+		t.Fatalf("默认不应该列出目录, 实际状态码为 %d", w.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"net/http"
+	"vgo/core/websocket"
+)
+
+// Upgrade upgrades the request to the WebSocket protocol using the settings in Engine.WebSocket.
+// On failure an HTTP error response has already been written to the client, so the handler
+// should simply return. After a successful upgrade the response must not be written through
+// the Context any more, all communication goes through the returned connection:
+//
+//	r.GET("/ws", func(c *core.Context) {
+//		conn, err := c.Upgrade()
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		for {
+//			mt, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			conn.WriteMessage(mt, msg)
+//		}
+//	})
+func (c *Context) Upgrade() (*websocket.Conn, error) {
+	return c.UpgradeWithHeader(nil)
+}
+
+// UpgradeWithHeader is like Upgrade but includes responseHeader in the handshake response,
+// e.g. to set a cookie.
+func (c *Context) UpgradeWithHeader(responseHeader http.Header) (*websocket.Conn, error) {
+	upgrader := &websocket.Upgrader{}
+	if c.engine != nil {
+		upgrader = &c.engine.WebSocket
+	}
+	conn, err := upgrader.Upgrade(c.Writer, c.Req, responseHeader)
+	if err != nil {
+		c.StatusCode = c.Writer.Status()
+		return nil, err
+	}
+	c.StatusCode = http.StatusSwitchingProtocols
+	return conn, nil
+}
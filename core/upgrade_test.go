@@ -0,0 +1,78 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"vgo/core/websocket"
+)
+
+// TestContextUpgrade 测试通过 Context.Upgrade 建立WebSocket连接，并使用 Engine.WebSocket 的配置
+func TestContextUpgrade(t *testing.T) {
+	r := New()
+	r.WebSocket.ReadLimit = 16
+	r.WebSocket.Subprotocols = []string{"echo"}
+	status := make(chan int, 1)
+	r.GET("/ws", func(c *Context) {
+		conn, err := c.Upgrade()
+		status <- c.StatusCode
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(mt, append([]byte("echo:"), msg...))
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	conn, _, err := (&websocket.Dialer{Subprotocols: []string{"echo"}}).Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if <-status != http.StatusSwitchingProtocols || conn.Subprotocol() != "echo" {
+		t.Fatalf("升级后的状态不正确: %q", conn.Subprotocol())
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte("hello"))
+	if _, msg, err := conn.ReadMessage(); err != nil || string(msg) != "echo:hello" {
+		t.Fatalf("消息回显不正确: %q %v", msg, err)
+	}
+	_ = conn.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", 17)))
+	if _, _, err = conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Fatalf("超出 Engine.WebSocket.ReadLimit 的消息应该关闭连接: %v", err)
+	}
+
+	_, resp, err := websocket.Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err != websocket.ErrBadHandshake || resp.StatusCode != http.StatusForbidden || <-status != http.StatusForbidden {
+		t.Fatalf("跨域的升级请求应该被拒绝: %v", err)
+	}
+}
+
+// TestContextUpgradeNotHijackable 测试底层连接无法接管时返回500，而不是把响应标记为已写入
+func TestContextUpgradeNotHijackable(t *testing.T) {
+	r := New()
+	upgradeErr := make(chan error, 1)
+	r.GET("/ws", func(c *Context) {
+		_, err := c.Upgrade()
+		upgradeErr <- err
+	})
+	req := httptest.NewRequest("GET", "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if err := <-upgradeErr; err == nil || w.Code != http.StatusInternalServerError {
+		t.Fatalf("无法接管连接时应该返回500: %d %v", w.Code, err)
+	}
+}
@@ -3,8 +3,29 @@ package core
 import (
 	"reflect"
 	"runtime"
+	"strings"
 )
 
 func nameOfFunction(f interface{}) string {
 	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 }
+
+// filterFlags 去掉媒体类型中的参数部分，例如 "application/json; charset=utf-8" -> "application/json"
+func filterFlags(content string) string {
+	for i, char := range content {
+		if char == ' ' || char == ';' {
+			return content[:i]
+		}
+	}
+	return content
+}
+
+// headerContainsToken 判断以逗号分隔的请求头中是否包含指定的token，忽略大小写
+func headerContainsToken(header, token string) bool {
+	for _, t := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrBadHandshake is returned when the server response to opening handshake is invalid.
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// Dialer contains options for connecting to WebSocket server.
+type Dialer struct {
+	// HandshakeTimeout specifies the duration for the handshake to complete.
+	HandshakeTimeout time.Duration
+
+	// TLSClientConfig specifies the TLS configuration to use with wss:// URLs.
+	TLSClientConfig *tls.Config
+
+	// ReadLimit is the maximum size in bytes of a message read from the server.
+	// Zero means the default limit of 32MB, a negative value disables the limit.
+	ReadLimit int64
+
+	// WriteFrameSize splits written messages into frames of at most this many bytes.
+	WriteFrameSize int
+
+	// Subprotocols specifies the client's requested subprotocols.
+	Subprotocols []string
+
+	// EnableCompression specifies if the client should attempt to negotiate
+	// per message compression (RFC 7692).
+	EnableCompression bool
+}
+
+// DefaultDialer is a dialer with all fields set to the default values.
+var DefaultDialer = &Dialer{HandshakeTimeout: 45 * time.Second}
+
+// Dial creates a new client connection using DefaultDialer.
+func Dial(urlStr string, requestHeader http.Header) (*Conn, *http.Response, error) {
+	return DefaultDialer.Dial(urlStr, requestHeader)
+}
+
+// Dial creates a new client connection. Use requestHeader to specify the
+// origin (Origin), cookies (Cookie) or other headers.
+//
+// If the WebSocket handshake fails, ErrBadHandshake is returned along with a
+// non-nil *http.Response so that callers can handle redirects, authentication, etc.
+func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (*Conn, *http.Response, error) {
+	ctx := context.Background()
+	if d.HandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.HandshakeTimeout)
+		defer cancel()
+	}
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, nil, errors.New("websocket: bad scheme " + u.Scheme)
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err = io.ReadFull(rand.Reader, keyBytes); err != nil {
+		return nil, nil, err
+	}
+	challengeKey := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, vs := range requestHeader {
+		if k == "Host" && len(vs) > 0 {
+			req.Host = vs[0]
+			continue
+		}
+		req.Header[k] = vs
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", challengeKey)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+	if d.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", "permessage-deflate; server_no_context_takeover; client_no_context_takeover")
+	}
+
+	hostPort := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			hostPort = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			hostPort = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	var netDialer net.Dialer
+	netConn, err := netDialer.DialContext(ctx, "tcp", hostPort)
+	if err != nil {
+		return nil, nil, err
+	}
+	success := false
+	defer func() {
+		if !success {
+			netConn.Close()
+		}
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = netConn.SetDeadline(deadline)
+	}
+
+	if u.Scheme == "https" {
+		cfg := &tls.Config{}
+		if d.TLSClientConfig != nil {
+			cfg = d.TLSClientConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(netConn, cfg)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, nil, err
+		}
+		netConn = tlsConn
+	}
+
+	if err = req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!tokenListContains(resp.Header, "Upgrade", "websocket") ||
+		!tokenListContains(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-Websocket-Accept") != computeAcceptKey(challengeKey) {
+		// 读取部分响应体方便调用方排查握手失败的原因
+		buf := make([]byte, 1024)
+		n, _ := io.ReadFull(resp.Body, buf)
+		resp.Body = io.NopCloser(strings.NewReader(string(buf[:n])))
+		return nil, resp, ErrBadHandshake
+	}
+
+	c := newConn(netConn, br, false)
+	c.subprotocol = resp.Header.Get("Sec-Websocket-Protocol")
+	c.writeFrameSize = d.WriteFrameSize
+	if d.ReadLimit != 0 {
+		c.SetReadLimit(d.ReadLimit)
+	}
+	for _, ext := range parseExtensions(resp.Header.Values("Sec-Websocket-Extensions")) {
+		if ext.name != "permessage-deflate" {
+			return nil, resp, errors.New("websocket: unsupported extension " + ext.name)
+		}
+		if !d.EnableCompression {
+			return nil, resp, errors.New("websocket: unexpected permessage-deflate response")
+		}
+		// 每条消息都用新的解压器读取，服务端必须不保留上下文
+		if _, ok := ext.params["server_no_context_takeover"]; !ok {
+			return nil, resp, errors.New("websocket: server_no_context_takeover not accepted by server")
+		}
+		c.compression = true
+		c.writeCompression = true
+	}
+
+	resp.Body = io.NopCloser(strings.NewReader(""))
+	_ = netConn.SetDeadline(time.Time{})
+	success = true
+	return c, resp, nil
+}
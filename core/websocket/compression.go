@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+)
+
+const (
+	minCompressionLevel     = -2 // flate.HuffmanOnly
+	maxCompressionLevel     = flate.BestCompression
+	defaultCompressionLevel = 1
+)
+
+// deflateTail 每条压缩消息末尾被去掉的空块，解压时需要补回
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// deflateFinal 补回的空块加上一个结束块，让flate reader读到EOF
+var deflateFinal = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+func isValidCompressionLevel(level int) bool {
+	return minCompressionLevel <= level && level <= maxCompressionLevel
+}
+
+// compressMessage 压缩一条消息，不保留上下文（no_context_takeover），每条消息独立压缩
+func compressMessage(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err = fw.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+// compressedLimit 解压后不超过 limit 的消息压缩后的最大长度，
+// 无法压缩的数据以存储块保存，每个块最多增加5个字节的开销
+func compressedLimit(limit int64) int64 {
+	return limit + (limit/65535+1)*5 + 64
+}
+
+// decompressMessage 解压一条消息，解压后的大小超过 limit 时返回 ErrReadLimit
+func decompressMessage(data []byte, limit int64) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateFinal)))
+	defer fr.Close()
+
+	var r io.Reader = fr
+	if limit > 0 {
+		r = io.LimitReader(fr, limit+1)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(len(out)) > limit {
+		return nil, ErrReadLimit
+	}
+	return out, nil
+}
+
+// extension Sec-WebSocket-Extensions 中的一项扩展及其参数
+type extension struct {
+	name   string
+	params map[string]string
+}
+
+// parseExtensions 解析 Sec-WebSocket-Extensions 头，例如
+// "permessage-deflate; client_max_window_bits, x-custom"
+func parseExtensions(values []string) []extension {
+	var exts []extension
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(item, ";")
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+			if name == "" {
+				continue
+			}
+			ext := extension{name: name, params: make(map[string]string)}
+			for _, p := range parts[1:] {
+				k, v := p, ""
+				if i := strings.IndexByte(p, '='); i >= 0 {
+					k, v = p[:i], strings.Trim(strings.TrimSpace(p[i+1:]), `"`)
+				}
+				ext.params[strings.ToLower(strings.TrimSpace(k))] = v
+			}
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// acceptDeflateOffer 判断客户端的 permessage-deflate 参数是否可以接受。
+// compress/flate 只支持32K窗口，因此拒绝要求缩小服务端窗口的请求
+func acceptDeflateOffer(ext extension) bool {
+	for k, v := range ext.params {
+		switch k {
+		case "server_no_context_takeover", "client_no_context_takeover":
+		case "client_max_window_bits":
+		case "server_max_window_bits":
+			if v != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// deflateResponse 服务端对 permessage-deflate 的应答，双方都不保留上下文
+const deflateResponse = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
@@ -0,0 +1,627 @@
+// Package websocket 基于RFC 6455实现的WebSocket协议，支持分片消息、ping/pong、关闭握手
+// 以及RFC 7692定义的 permessage-deflate 压缩扩展。
+//
+// 服务端通过 Upgrader 将HTTP请求升级为连接，客户端通过 Dialer 建立连接，
+// 两端都使用 Conn.ReadMessage 与 Conn.WriteMessage 收发消息。
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型，与帧的opcode一致
+const (
+	// TextMessage denotes a text data message. The text message payload is
+	// interpreted as UTF-8 encoded text data.
+	TextMessage = 1
+	// BinaryMessage denotes a binary data message.
+	BinaryMessage = 2
+	// CloseMessage denotes a close control message. The optional message
+	// payload contains a numeric code and text. Use the FormatCloseMessage
+	// function to format a close message payload.
+	CloseMessage = 8
+	// PingMessage denotes a ping control message. The optional message payload
+	// is UTF-8 encoded text.
+	PingMessage = 9
+	// PongMessage denotes a pong control message. The optional message payload
+	// is UTF-8 encoded text.
+	PongMessage = 10
+
+	continuationFrame = 0
+)
+
+// Close codes defined in RFC 6455, section 11.7.
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+	CloseServiceRestart          = 1012
+	CloseTryAgainLater           = 1013
+	CloseTLSHandshake            = 1015
+)
+
+const (
+	finalBit = 1 << 7
+	rsv1Bit  = 1 << 6
+	rsv2Bit  = 1 << 5
+	rsv3Bit  = 1 << 4
+	maskBit  = 1 << 7
+
+	maxControlFramePayloadSize = 125
+
+	// defaultReadLimit 默认单条消息的最大字节数
+	defaultReadLimit = 32 << 20
+)
+
+var (
+	// ErrReadLimit is returned when reading a message that is larger than the read limit set for the connection.
+	ErrReadLimit = errors.New("websocket: read limit exceeded")
+	// ErrCloseSent is returned when the application writes a message to the connection after sending a close message.
+	ErrCloseSent = errors.New("websocket: close sent")
+
+	errInvalidControlFrame = errors.New("websocket: invalid control frame")
+	errBadWriteOpCode      = errors.New("websocket: bad write message type")
+)
+
+// CloseError represents a close message.
+type CloseError struct {
+	// Code is defined in RFC 6455, section 11.7.
+	Code int
+	// Text is the optional text payload.
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket: close " + strconv.Itoa(e.Code)
+	if e.Text != "" {
+		s += ": " + e.Text
+	}
+	return s
+}
+
+// IsCloseError returns boolean indicating whether the error is a *CloseError with one of the specified codes.
+func IsCloseError(err error, codes ...int) bool {
+	var e *CloseError
+	if errors.As(err, &e) {
+		for _, code := range codes {
+			if e.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FormatCloseMessage formats closeCode and text as a WebSocket close message.
+// An empty message is returned for code CloseNoStatusReceived.
+func FormatCloseMessage(closeCode int, text string) []byte {
+	if closeCode == CloseNoStatusReceived {
+		return []byte{}
+	}
+	buf := make([]byte, 2+len(text))
+	binary.BigEndian.PutUint16(buf, uint16(closeCode))
+	copy(buf[2:], text)
+	return buf
+}
+
+// isValidReceivedCloseCode 判断收到的关闭码是否合法，1005、1006、1015只能在本地使用，不能出现在帧中
+func isValidReceivedCloseCode(code int) bool {
+	switch code {
+	case CloseNormalClosure, CloseGoingAway, CloseProtocolError, CloseUnsupportedData,
+		CloseInvalidFramePayloadData, ClosePolicyViolation, CloseMessageTooBig,
+		CloseMandatoryExtension, CloseInternalServerErr, CloseServiceRestart, CloseTryAgainLater:
+		return true
+	}
+	return code >= 3000 && code <= 4999
+}
+
+// Conn represents a WebSocket connection.
+// Applications may call one reader method (ReadMessage) and one writer method
+// (WriteMessage, WriteControl, WriteClose) concurrently.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+
+	// compression 握手时协商了 permessage-deflate
+	compression      bool
+	writeCompression bool
+	compressionLevel int
+
+	readLimit      int64
+	writeFrameSize int
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	// readErr 读取出错后，之后的读取都返回该错误
+	readErr error
+
+	handlePing  func(appData string) error
+	handlePong  func(appData string) error
+	handleClose func(code int, text string) error
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:             conn,
+		br:               br,
+		isServer:         isServer,
+		readLimit:        defaultReadLimit,
+		compressionLevel: defaultCompressionLevel,
+	}
+	c.SetPingHandler(nil)
+	c.SetPongHandler(nil)
+	c.SetCloseHandler(nil)
+	return c
+}
+
+// Subprotocol returns the negotiated protocol for the connection.
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// LocalAddr returns the local network address.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the underlying network connection without sending or waiting for a close message.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// SetReadDeadline sets the read deadline on the underlying network connection.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline on the underlying network connection.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit sets the maximum size in bytes for a message read from the peer, after decompression.
+// If a message exceeds the limit, the connection sends a close message with CloseMessageTooBig
+// to the peer and returns ErrReadLimit to the application.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// EnableWriteCompression enables and disables write compression of subsequent text and binary messages.
+// This function is a noop if compression was not negotiated with the peer.
+func (c *Conn) EnableWriteCompression(enable bool) {
+	c.writeCompression = enable
+}
+
+// SetCompressionLevel sets the flate compression level for subsequent text and binary messages.
+func (c *Conn) SetCompressionLevel(level int) error {
+	if !isValidCompressionLevel(level) {
+		return errors.New("websocket: invalid compression level")
+	}
+	c.compressionLevel = level
+	return nil
+}
+
+// SetPingHandler sets the handler for ping messages received from the peer.
+// The default ping handler sends a pong to the peer.
+func (c *Conn) SetPingHandler(h func(appData string) error) {
+	if h == nil {
+		h = func(message string) error {
+			err := c.WriteControl(PongMessage, []byte(message))
+			if err == ErrCloseSent {
+				return nil
+			}
+			return err
+		}
+	}
+	c.handlePing = h
+}
+
+// SetPongHandler sets the handler for pong messages received from the peer.
+// The default pong handler does nothing.
+func (c *Conn) SetPongHandler(h func(appData string) error) {
+	if h == nil {
+		h = func(string) error { return nil }
+	}
+	c.handlePong = h
+}
+
+// SetCloseHandler sets the handler for close messages received from the peer.
+// The default close handler sends a close message back to the peer, ReadMessage then returns a *CloseError.
+func (c *Conn) SetCloseHandler(h func(code int, text string) error) {
+	if h == nil {
+		h = func(code int, text string) error {
+			if code == CloseNoStatusReceived {
+				code = CloseNormalClosure
+				text = ""
+			}
+			err := c.WriteClose(code, text)
+			if err == ErrCloseSent {
+				return nil
+			}
+			return err
+		}
+	}
+	c.handleClose = h
+}
+
+/************************************/
+/************** WRITING *************/
+/************************************/
+
+// WriteMessage writes a message with the given message type and payload.
+// Text and binary messages are compressed when compression was negotiated, and split
+// into several frames when the connection has a write frame size.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		return c.WriteControl(messageType, data)
+	default:
+		return errBadWriteOpCode
+	}
+
+	compressed := false
+	if c.compression && c.writeCompression {
+		var err error
+		if data, err = compressMessage(data, c.compressionLevel); err != nil {
+			return err
+		}
+		compressed = true
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	opcode := messageType
+	for first := true; first || len(data) > 0; first = false {
+		frame := data
+		if c.writeFrameSize > 0 && len(frame) > c.writeFrameSize {
+			frame = data[:c.writeFrameSize]
+		}
+		data = data[len(frame):]
+		// RSV1只在消息的第一帧上设置
+		if err := c.writeFrame(len(data) == 0, compressed && first, opcode, frame); err != nil {
+			return err
+		}
+		opcode = continuationFrame
+	}
+	return nil
+}
+
+// WriteControl writes a control message (close, ping or pong) with a payload of at most 125 bytes.
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	if messageType != CloseMessage && messageType != PingMessage && messageType != PongMessage {
+		return errBadWriteOpCode
+	}
+	if len(data) > maxControlFramePayloadSize {
+		return errInvalidControlFrame
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return c.writeFrame(true, false, messageType, data)
+}
+
+// WriteClose sends a close message with the given code and text. No message other than
+// the close message can be written afterwards.
+func (c *Conn) WriteClose(code int, text string) error {
+	return c.WriteControl(CloseMessage, FormatCloseMessage(code, text))
+}
+
+// writeFrame 写入一帧，调用方需要持有 writeMu。客户端发送的帧必须使用随机掩码
+func (c *Conn) writeFrame(fin, rsv1 bool, opcode int, payload []byte) error {
+	header := make([]byte, 0, 14)
+	b0 := byte(opcode)
+	if fin {
+		b0 |= finalBit
+	}
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	header = append(header, b0)
+
+	var b1 byte
+	if !c.isServer {
+		b1 |= maskBit
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		header = append(header, b1|byte(n))
+	case n <= 65535:
+		header = append(header, b1|126, byte(n>>8), byte(n))
+	default:
+		header = append(header, b1|127)
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[len(header)-8:], uint64(n))
+	}
+
+	if !c.isServer {
+		var key [4]byte
+		if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+			return err
+		}
+		header = append(header, key[:]...)
+		masked := make([]byte, len(payload))
+		copy(masked, payload)
+		maskBytes(key, masked)
+		payload = masked
+	}
+
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// maskBytes 使用掩码对负载做异或，掩码与解码是同一个操作
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i&3]
+	}
+}
+
+/************************************/
+/************** READING *************/
+/************************************/
+
+// frame 一个已经读取并去掉掩码的帧
+type frame struct {
+	fin     bool
+	rsv1    bool
+	opcode  int
+	payload []byte
+}
+
+// readFrame 读取一帧并校验帧头，length 为当前消息已经读取的字节数，用于限制消息大小，
+// compressed 表示当前消息是压缩消息
+func (c *Conn) readFrame(length int64, compressed bool) (*frame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return nil, err
+	}
+	f := &frame{
+		fin:    head[0]&finalBit != 0,
+		rsv1:   head[0]&rsv1Bit != 0,
+		opcode: int(head[0] & 0x0f),
+	}
+	if head[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return nil, c.protocolError("unexpected reserved bits")
+	}
+	masked := head[1]&maskBit != 0
+	if masked != c.isServer {
+		return nil, c.protocolError("incorrect mask flag")
+	}
+
+	n := int64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		n = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return nil, err
+		}
+		u := binary.BigEndian.Uint64(ext[:])
+		if u>>63 != 0 {
+			return nil, c.protocolError("invalid payload length")
+		}
+		n = int64(u)
+	}
+
+	switch f.opcode {
+	case CloseMessage, PingMessage, PongMessage:
+		if n > maxControlFramePayloadSize || !f.fin {
+			return nil, c.protocolError("invalid control frame")
+		}
+		if f.rsv1 {
+			return nil, c.protocolError("compressed control frame")
+		}
+	case TextMessage, BinaryMessage, continuationFrame:
+		if f.rsv1 && (!c.compression || f.opcode == continuationFrame) {
+			return nil, c.protocolError("unexpected compressed frame")
+		}
+		limit := c.readLimit
+		if limit > 0 && (compressed || f.rsv1) {
+			// 压缩后的数据可能比原文略大，解压后还会再次校验上限
+			limit = compressedLimit(limit)
+		}
+		if limit > 0 && length+n > limit {
+			return nil, c.readLimitError()
+		}
+		if n > math.MaxInt64-length {
+			return nil, c.readLimitError()
+		}
+	default:
+		return nil, c.protocolError("unknown opcode " + strconv.Itoa(f.opcode))
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return nil, err
+		}
+	}
+	payload, err := readPayload(c.br, n)
+	if err != nil {
+		return nil, err
+	}
+	f.payload = payload
+	if masked {
+		maskBytes(key, f.payload)
+	}
+	return f, nil
+}
+
+// maxPreallocSize 一次性分配的帧负载上限，更大的帧随读取逐步扩容
+const maxPreallocSize = 64 << 10
+
+// readPayload 读取n字节的帧负载
+// 不限制读取大小时帧头中的长度不可信，只按实际收到的数据扩容，避免恶意长度导致一次性分配大量内存
+func readPayload(r io.Reader, n int64) ([]byte, error) {
+	if n <= maxPreallocSize {
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, err
+		}
+		return payload, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(maxPreallocSize)
+	if _, err := io.CopyN(&buf, r, n); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// protocolError 向对端发送1002关闭帧并返回错误
+func (c *Conn) protocolError(message string) error {
+	_ = c.WriteClose(CloseProtocolError, "")
+	return errors.New("websocket: " + message)
+}
+
+// readLimitError 向对端发送1009关闭帧并返回 ErrReadLimit
+func (c *Conn) readLimitError() error {
+	_ = c.WriteClose(CloseMessageTooBig, "")
+	return ErrReadLimit
+}
+
+// handleControl 处理读取过程中穿插的控制帧
+func (c *Conn) handleControl(f *frame) error {
+	switch f.opcode {
+	case PingMessage:
+		return c.handlePing(string(f.payload))
+	case PongMessage:
+		return c.handlePong(string(f.payload))
+	}
+
+	code, text := CloseNoStatusReceived, ""
+	if len(f.payload) == 1 {
+		return c.protocolError("invalid close payload")
+	}
+	if len(f.payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(f.payload))
+		if !isValidReceivedCloseCode(code) {
+			return c.protocolError("invalid close code")
+		}
+		text = string(f.payload[2:])
+		if !utf8.ValidString(text) {
+			_ = c.WriteClose(CloseInvalidFramePayloadData, "")
+			return errors.New("websocket: invalid utf8 payload in close frame")
+		}
+	}
+	if err := c.handleClose(code, text); err != nil {
+		return err
+	}
+	return &CloseError{Code: code, Text: text}
+}
+
+// ReadMessage reads the next text or binary message, reassembling fragmented messages
+// and answering control frames received in between.
+// A *CloseError is returned once the peer closes the connection. Once an error is returned,
+// every later call returns the same error.
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, p, err = c.readMessage()
+	if err != nil {
+		c.readErr = err
+	}
+	return messageType, p, err
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	messageType := 0
+	compressed := false
+	var message []byte
+
+	for {
+		f, err := c.readFrame(int64(len(message)), compressed)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case CloseMessage, PingMessage, PongMessage:
+			if err = c.handleControl(f); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.protocolError("continuation frame without a started message")
+			}
+		default:
+			if messageType != 0 {
+				return 0, nil, c.protocolError("data frame inside a fragmented message")
+			}
+			messageType = f.opcode
+			compressed = f.rsv1
+		}
+
+		message = append(message, f.payload...)
+		if !f.fin {
+			continue
+		}
+
+		if compressed {
+			if message, err = decompressMessage(message, c.readLimit); err != nil {
+				if err == ErrReadLimit {
+					return 0, nil, c.readLimitError()
+				}
+				return 0, nil, c.protocolError("invalid compressed data")
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(message) {
+			_ = c.WriteClose(CloseInvalidFramePayloadData, "")
+			return 0, nil, errors.New("websocket: invalid utf8 payload in text message")
+		}
+		return messageType, message, nil
+	}
+}
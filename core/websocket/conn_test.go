@@ -0,0 +1,274 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newEchoServer 启动一个使用 upgrader 的回显服务，返回ws地址
+func newEchoServer(t *testing.T, upgrader *Upgrader) (string, func()) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, http.Header{"X-Echo": {"1"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err = conn.WriteMessage(mt, msg); err != nil {
+				return
+			}
+		}
+	}))
+	return "ws" + strings.TrimPrefix(srv.URL, "http"), srv.Close
+}
+
+// TestEcho 测试握手、文本与二进制消息以及不同长度编码的帧
+func TestEcho(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{Subprotocols: []string{"chat"}})
+	defer closeServer()
+
+	d := &Dialer{Subprotocols: []string{"superchat", "chat"}}
+	conn, resp, err := d.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != "chat" || resp.Header.Get("X-Echo") != "1" {
+		t.Fatalf("握手响应不正确: %q %v", conn.Subprotocol(), resp.Header)
+	}
+
+	for _, size := range []int{0, 125, 126, 65535, 65536} {
+		msg := bytes.Repeat([]byte("a"), size)
+		if err = conn.WriteMessage(BinaryMessage, msg); err != nil {
+			t.Fatal(err)
+		}
+		mt, got, err := conn.ReadMessage()
+		if err != nil || mt != BinaryMessage || !bytes.Equal(got, msg) {
+			t.Fatalf("%d字节的消息回显不正确: %d %d %v", size, mt, len(got), err)
+		}
+	}
+	_ = conn.WriteMessage(TextMessage, []byte("你好"))
+	if mt, got, _ := conn.ReadMessage(); mt != TextMessage || string(got) != "你好" {
+		t.Fatalf("文本消息回显不正确: %d %q", mt, got)
+	}
+}
+
+// TestFragmentationAndCompression 测试分片消息的重组与 permessage-deflate 压缩
+func TestFragmentationAndCompression(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{EnableCompression: true, WriteFrameSize: 7})
+	defer closeServer()
+
+	conn, resp, err := (&Dialer{EnableCompression: true, WriteFrameSize: 5}).Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if !strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate") || !conn.compression {
+		t.Fatalf("应该协商压缩扩展: %v", resp.Header)
+	}
+
+	msg := strings.Repeat("hello websocket ", 100)
+	for i := 0; i < 2; i++ {
+		_ = conn.WriteMessage(TextMessage, []byte(msg))
+		if _, got, err := conn.ReadMessage(); err != nil || string(got) != msg {
+			t.Fatalf("压缩分片消息回显不正确: %q %v", got, err)
+		}
+	}
+
+	conn.EnableWriteCompression(false)
+	_ = conn.WriteMessage(TextMessage, []byte(msg))
+	if _, got, err := conn.ReadMessage(); err != nil || string(got) != msg {
+		t.Fatalf("关闭压缩后消息回显不正确: %q %v", got, err)
+	}
+
+	_, resp, err = Dial(url, nil)
+	if err != nil || resp.Header.Get("Sec-Websocket-Extensions") != "" {
+		t.Fatalf("客户端没有请求时不应该协商压缩: %v %v", resp.Header, err)
+	}
+}
+
+// TestPingPongAndClose 测试ping自动回复pong以及关闭握手
+func TestPingPongAndClose(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{})
+	defer closeServer()
+
+	conn, _, err := Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	_ = conn.WriteControl(PingMessage, []byte("ping"))
+	_ = conn.WriteMessage(TextMessage, []byte("after ping"))
+	if _, got, _ := conn.ReadMessage(); string(got) != "after ping" {
+		t.Fatalf("控制帧不应该打断数据消息: %q", got)
+	}
+	if <-pong != "ping" {
+		t.Fatal("pong应该携带ping的数据")
+	}
+
+	if err = conn.WriteControl(PingMessage, bytes.Repeat([]byte("a"), 126)); err != errInvalidControlFrame {
+		t.Fatalf("控制帧负载不能超过125字节: %v", err)
+	}
+
+	_ = conn.WriteClose(CloseGoingAway, "bye")
+	_, _, err = conn.ReadMessage()
+	if !IsCloseError(err, CloseGoingAway) || err.(*CloseError).Text != "bye" {
+		t.Fatalf("服务端应该回显关闭帧: %v", err)
+	}
+	if err = conn.WriteMessage(TextMessage, []byte("x")); err != ErrCloseSent {
+		t.Fatalf("发送关闭帧后不能再写入消息: %v", err)
+	}
+	if _, _, again := conn.ReadMessage(); again != err && !IsCloseError(again, CloseGoingAway) {
+		t.Fatalf("出错后再次读取应该返回同样的错误: %v", again)
+	}
+}
+
+// TestReadLimit 测试超出读取上限的消息会以1009关闭连接，包括解压后超出上限的消息
+func TestReadLimit(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{ReadLimit: 64, EnableCompression: true})
+	defer closeServer()
+
+	for _, compress := range []bool{false, true} {
+		conn, _, err := (&Dialer{EnableCompression: compress}).Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.WriteMessage(TextMessage, []byte(strings.Repeat("a", 64)))
+		if _, _, err = conn.ReadMessage(); err != nil {
+			t.Fatalf("没有超出上限的消息应该正常读取 compress=%v: %v", compress, err)
+		}
+		// 压缩后只有几个字节，但解压后超出上限
+		_ = conn.WriteMessage(TextMessage, []byte(strings.Repeat("a", 1000)))
+		if _, _, err = conn.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+			t.Fatalf("超出上限时应该收到1009关闭帧 compress=%v: %v", compress, err)
+		}
+		conn.Close()
+	}
+}
+
+// TestUnlimitedReadHostileLength 测试不限制读取大小时，帧头声明的超大长度不会导致一次性分配内存
+func TestUnlimitedReadHostileLength(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+	conn := newConn(server, nil, true)
+	conn.SetReadLimit(-1)
+
+	go func() {
+		// 声明长度为 2^62 字节、带掩码的二进制帧，随后只发送少量数据就关闭连接
+		_, _ = client.Write([]byte{0x82, 0xff, 0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+		_, _ = client.Write(bytes.Repeat([]byte("a"), 1024))
+		client.Close()
+	}()
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Fatal("数据不足时应该返回错误")
+	}
+}
+
+// TestUpgradeRejected 测试握手校验失败时返回HTTP错误
+func TestUpgradeRejected(t *testing.T) {
+	upgrader := &Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	_, resp, err := Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err != ErrBadHandshake || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("跨域请求应该被拒绝: %v", err)
+	}
+	conn, _, err := Dial(url, http.Header{"Origin": {"http://" + strings.TrimPrefix(srv.URL, "http://")}})
+	if err != nil {
+		t.Fatalf("同源请求应该允许升级: %v", err)
+	}
+	conn.Close()
+
+	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	if conn, _, err = Dial(url, http.Header{"Origin": {"http://evil.example"}}); err != nil {
+		t.Fatalf("CheckOrigin 应该可以放开跨域校验: %v", err)
+	}
+	conn.Close()
+
+	resp, err = http.Get(srv.URL)
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("普通HTTP请求应该返回400: %v", err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUpgradeRequired || resp.Header.Get("Sec-WebSocket-Version") != "13" {
+		t.Fatalf("不支持的版本应该返回426: %v", err)
+	}
+}
+
+// TestProtocolErrors 测试非法帧会以1002关闭连接
+func TestProtocolErrors(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{})
+	defer closeServer()
+
+	frames := map[string][]byte{
+		"未加掩码的帧":   {0x81, 0x01, 'a'},
+		"保留位":      {0xa1, 0x80, 0, 0, 0, 0},
+		"未知opcode": {0x83, 0x80, 0, 0, 0, 0},
+		"分片的控制帧":   {0x09, 0x80, 0, 0, 0, 0},
+		"孤立的续帧":    {0x80, 0x80, 0, 0, 0, 0},
+	}
+	for name, frame := range frames {
+		conn, _, err := Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = conn.conn.Write(frame)
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, _, err = conn.ReadMessage(); !IsCloseError(err, CloseProtocolError) {
+			t.Fatalf("%s应该以1002关闭连接: %v", name, err)
+		}
+		conn.Close()
+	}
+}
+
+// TestServerBufferedFrames 测试握手请求后紧跟的帧不会丢失
+func TestServerBufferedFrames(t *testing.T) {
+	url, closeServer := newEchoServer(t, &Upgrader{})
+	defer closeServer()
+
+	nc, err := net.Dial("tcp", strings.TrimPrefix(url, "ws://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+	// 掩码为0的 "hi" 文本帧
+	_, _ = nc.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n" +
+		"\x81\x82\x00\x00\x00\x00hi"))
+
+	br := bufio.NewReader(nc)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil || resp.Header.Get("Sec-Websocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("握手响应不正确: %v", err)
+	}
+	frame := make([]byte, 4)
+	if _, err = br.Read(frame); err != nil || string(frame) != "\x81\x02hi" {
+		t.Fatalf("应该回显握手后立即发送的帧: %q %v", frame, err)
+	}
+}
@@ -0,0 +1,229 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// keyGUID RFC 6455 中用于计算 Sec-WebSocket-Accept 的固定GUID
+const keyGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// HandshakeError describes an error with the handshake from the peer.
+type HandshakeError struct {
+	message string
+}
+
+func (e HandshakeError) Error() string { return e.message }
+
+// Upgrader specifies parameters for upgrading an HTTP connection to a WebSocket connection.
+type Upgrader struct {
+	// HandshakeTimeout specifies the duration for the handshake to complete.
+	HandshakeTimeout time.Duration
+
+	// ReadLimit is the maximum size in bytes of a message read from the peer.
+	// Zero means the default limit of 32MB, a negative value disables the limit.
+	ReadLimit int64
+
+	// WriteFrameSize splits written messages into frames of at most this many bytes.
+	// Zero writes every message as a single frame.
+	WriteFrameSize int
+
+	// Subprotocols specifies the server's supported protocols in order of preference.
+	Subprotocols []string
+
+	// CheckOrigin returns true if the request Origin header is acceptable.
+	// If CheckOrigin is nil, requests with an Origin header whose host
+	// differs from the Host header are rejected.
+	CheckOrigin func(r *http.Request) bool
+
+	// EnableCompression specifies if the server should attempt to negotiate
+	// per message compression (RFC 7692).
+	EnableCompression bool
+
+	// Error specifies the function for generating HTTP error responses.
+	// If Error is nil, http.Error is used.
+	Error func(w http.ResponseWriter, r *http.Request, status int, reason error)
+}
+
+func (u *Upgrader) returnError(w http.ResponseWriter, r *http.Request, status int, reason string) (*Conn, error) {
+	err := HandshakeError{message: "websocket: " + reason}
+	if u.Error != nil {
+		u.Error(w, r, status, err)
+	} else {
+		w.Header().Set("Sec-Websocket-Version", "13")
+		http.Error(w, http.StatusText(status), status)
+	}
+	return nil, err
+}
+
+// checkSameOrigin 没有Origin头或者Origin的host与请求的Host一致时通过
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	clientProtocols := Subprotocols(r)
+	for _, serverProtocol := range u.Subprotocols {
+		for _, clientProtocol := range clientProtocols {
+			if clientProtocol == serverProtocol {
+				return clientProtocol
+			}
+		}
+	}
+	return ""
+}
+
+// Subprotocols returns the subprotocols requested by the client in the Sec-WebSocket-Protocol header.
+func Subprotocols(r *http.Request) []string {
+	var protocols []string
+	for _, value := range r.Header.Values("Sec-Websocket-Protocol") {
+		for _, p := range strings.Split(value, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				protocols = append(protocols, p)
+			}
+		}
+	}
+	return protocols
+}
+
+// IsWebSocketUpgrade returns true if the client requested upgrade to the WebSocket protocol.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return tokenListContains(r.Header, "Connection", "upgrade") &&
+		tokenListContains(r.Header, "Upgrade", "websocket")
+}
+
+// tokenListContains 判断逗号分隔的头部值中是否包含 token，忽略大小写
+func tokenListContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// computeAcceptKey 根据客户端的 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func computeAcceptKey(challengeKey string) string {
+	h := sha1.New()
+	h.Write([]byte(challengeKey))
+	h.Write([]byte(keyGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Upgrade upgrades the HTTP server connection to the WebSocket protocol.
+//
+// The responseHeader is included in the response to the client's upgrade
+// request. If the upgrade fails, Upgrade replies to the client with an HTTP error response.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return u.returnError(w, r, http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !tokenListContains(r.Header, "Connection", "upgrade") {
+		return u.returnError(w, r, http.StatusBadRequest, "'upgrade' token not found in 'Connection' header")
+	}
+	if !tokenListContains(r.Header, "Upgrade", "websocket") {
+		return u.returnError(w, r, http.StatusBadRequest, "'websocket' token not found in 'Upgrade' header")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		return u.returnError(w, r, http.StatusUpgradeRequired, "unsupported version: 13 not found in 'Sec-Websocket-Version' header")
+	}
+
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		return u.returnError(w, r, http.StatusForbidden, "request origin not allowed by Upgrader.CheckOrigin")
+	}
+
+	challengeKey := r.Header.Get("Sec-Websocket-Key")
+	if key, err := base64.StdEncoding.DecodeString(challengeKey); err != nil || len(key) != 16 {
+		return u.returnError(w, r, http.StatusBadRequest, "not a websocket handshake: 'Sec-WebSocket-Key' header must be Base64 encoded value of 16-byte in length")
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+
+	compress := false
+	if u.EnableCompression {
+		for _, ext := range parseExtensions(r.Header.Values("Sec-Websocket-Extensions")) {
+			if ext.name == "permessage-deflate" && acceptDeflateOffer(ext) {
+				compress = true
+				break
+			}
+		}
+	}
+
+	netConn, brw, err := hijack(w)
+	if err != nil {
+		return u.returnError(w, r, http.StatusInternalServerError, err.Error())
+	}
+
+	c := newConn(netConn, brw.Reader, true)
+	c.subprotocol = subprotocol
+	c.compression = compress
+	c.writeCompression = compress
+	c.writeFrameSize = u.WriteFrameSize
+	if u.ReadLimit != 0 {
+		c.SetReadLimit(u.ReadLimit)
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	b.WriteString(computeAcceptKey(challengeKey))
+	b.WriteString("\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: " + deflateResponse + "\r\n")
+	}
+	for k, vs := range responseHeader {
+		if k == "Sec-Websocket-Protocol" || k == "Sec-Websocket-Extensions" {
+			continue
+		}
+		for _, v := range vs {
+			b.WriteString(k + ": " + strings.NewReplacer("\r", "", "\n", "").Replace(v) + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+
+	if u.HandshakeTimeout > 0 {
+		_ = netConn.SetWriteDeadline(time.Now().Add(u.HandshakeTimeout))
+	}
+	if _, err = netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if u.HandshakeTimeout > 0 {
+		_ = netConn.SetWriteDeadline(time.Time{})
+	}
+	return c, nil
+}
+
+// hijack 接管底层连接
+// 框架的 ResponseWriter 之类的包装类型总是实现 http.Hijacker，底层是否支持只能从 Hijack 的错误得知，
+// 因此两种失败统一通过返回的错误处理
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("websocket: response does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"crypto/rand"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"os"
 	"vgo/core"
 	"vgo/log"
 )
@@ -27,35 +29,62 @@ func Cors() core.HandlerFunc {
 
 const TestLogPath = "D:\\log.txt" // 测试日志路径
 
+// CookieKeyEnv 加密cookie密钥的环境变量
+const CookieKeyEnv = "VGO_COOKIE_KEY"
+
+// cookieKey 从环境变量读取加密cookie密钥，没有设置时随机生成，重启后之前签发的cookie会失效
+func cookieKey() []byte {
+	if key := os.Getenv(CookieKeyEnv); key != "" {
+		return []byte(key)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	log.Warn("未设置 " + CookieKeyEnv + "，使用随机生成的cookie密钥")
+	return key
+}
+
 func main() {
 	// 1.构建框架环境
 	r := core.New()
 	//log.SetLogPath(TestLogPath) // 自定义设置日志输出路径
+	r.SetCookieKeys(cookieKey()) // 设置加密cookie密钥
 	// 2. 注册中间件
 	r.Use(Logger())
 
 	// 3. 注册路由，跨域报错 -> 无法访问。
 	r.GET("/log/list", logList)
+	r.GET("/log/export", logExport)
+	r.GET("/log/stream", logStream)
 	r.POST("/login", login)
+	r.GET("/session", session)
 	r.GET("/ping", func(c *core.Context) {
 		c.JSON(200, gin.H{
 			"message": "pong",
 		})
 	})
 
-	// 4. 注册分组路由
+	// 4. 加载HTML模板，渲染demo页面及其依赖的静态资源
+	r.LoadHTMLGlob("./demo/*.html")
+	for _, page := range []string{"login.html", "list.html", "auth.html"} {
+		r.GET("/"+page, htmlPage(page))
+	}
+	r.Static("/bootstrap-3.4.1-dist", "./demo/bootstrap-3.4.1-dist")
+
+	// 5. 注册分组路由
 	gr := r.Group("/cors")
 	gr.Use(Cors()) // cors分组下使用cors中间件设置跨域
 	{
 		gr.GET("/log/list", logList)
 		gr.POST("/login", login)
 
-		// 5. 动态路由 - 参数匹配
+		// 6. 动态路由 - 参数匹配
 		gr.GET("/hello/:name/space", func(ctx *core.Context) {
 			ctx.String(http.StatusOK, "The dynamic routing passes in parameters: %s", ctx.Params["name"])
 		})
 
-		// 6. 动态路由 - 模糊匹配
+		// 7. 动态路由 - 模糊匹配
 		gr.GET("/static/*filepath", func(ctx *core.Context) {
 			ctx.String(http.StatusOK, "The dynamic routing passes in parameters: /%s", ctx.Params["filepath"])
 		})
@@ -5,17 +5,37 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 	"vgo/core"
 	"vgo/log"
 )
 
+// htmlPage 渲染模板页面
+func htmlPage(name string) core.HandlerFunc {
+	return func(ctx *core.Context) {
+		ctx.HTML(http.StatusOK, name, nil)
+	}
+}
+
 // 登录接口 - 测试路由POST方法
 func login(ctx *core.Context) {
 	username := ctx.PostForm("username")
 	password := ctx.PostForm("password")
 	if username == "pjx@cq.com" && password == "1104" {
 		log.Info("用户登录成功")
+		// 使用加密cookie保存会话，无需服务端存储
+		if err := ctx.SetSecureCookie("session", username, 3600, "/", "", false, true); err != nil {
+			ctx.Fail()
+			return
+		}
+		// 表单提交时携带next参数则跳转回原页面，只允许站内地址，防止开放重定向
+		if next := ctx.PostForm("next"); next != "" {
+			if err := ctx.SafeRedirect(http.StatusFound, next); err == nil {
+				return
+			}
+		}
 		ctx.JSON(http.StatusOK, core.H{
 			"success": "success",
 		})
@@ -25,6 +45,20 @@ func login(ctx *core.Context) {
 	}
 }
 
+// 当前会话 - 读取登录时写入的加密cookie
+func session(ctx *core.Context) {
+	username, err := ctx.SecureCookie("session", 3600)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, core.H{
+			"error": "not logged in",
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, core.H{
+		"username": username,
+	})
+}
+
 // 日志列表 - 测试路由GET方法
 func logList(ctx *core.Context) {
 	file, err := os.Open("./log.txt")
@@ -48,3 +82,50 @@ func logList(ctx *core.Context) {
 	}
 	ctx.JSON(http.StatusOK, list)
 }
+
+// 日志导出 - 以附件形式流式返回日志文件，支持断点续传
+func logExport(ctx *core.Context) {
+	ctx.FileAttachment("./log.txt", "log.txt")
+}
+
+// 日志推送 - 通过Server-Sent Events推送新增的日志，事件id为日志文件的偏移量，断线重连时从上次的位置继续推送
+func logStream(ctx *core.Context) {
+	file, err := os.Open("./log.txt")
+	if err != nil {
+		ctx.Fail()
+		return
+	}
+	defer file.Close()
+
+	offset, err := strconv.ParseInt(ctx.LastEventID(), 10, 64)
+	if err != nil {
+		// 首次连接只推送新增的日志
+		offset, _ = file.Seek(0, io.SeekEnd)
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		ctx.Fail()
+		return
+	}
+
+	buf := bufio.NewReader(file)
+	ctx.Stream(func(w io.Writer) bool {
+		line, inErr := buf.ReadString('\n')
+		if inErr == io.EOF {
+			// 没有完整的新行时把已读取的部分退回，等待日志继续写入
+			offset, _ = file.Seek(offset, io.SeekStart)
+			buf.Reset(file)
+			time.Sleep(time.Second)
+			return true
+		}
+		if inErr != nil {
+			return false
+		}
+		offset += int64(len(line))
+		_ = ctx.WriteSSE(core.SSEvent{
+			Id:    strconv.FormatInt(offset, 10),
+			Event: "log",
+			Data:  strings.TrimSpace(line),
+		})
+		return true
+	})
+}
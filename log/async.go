@@ -0,0 +1,235 @@
+package log
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrWriterClosed is returned when writing to a closed AsyncWriter.
+var ErrWriterClosed = errors.New("log: writer closed")
+
+// OverflowPolicy 异步缓冲区满时的处理策略
+type OverflowPolicy int
+
+const (
+	// Block 缓冲区满时阻塞写入，直到后台写出腾出空间，不会丢失日志
+	Block OverflowPolicy = iota
+	// Drop 缓冲区满时丢弃新的日志，并计入 AsyncWriter.Dropped，写入方不会被阻塞
+	Drop
+)
+
+const (
+	defaultAsyncBufferSize    = 1024
+	defaultAsyncFlushInterval = time.Second
+)
+
+// AsyncOptions AsyncWriter 的配置
+type AsyncOptions struct {
+	// BufferSize 缓冲区最多容纳的日志条数，默认1024
+	BufferSize int
+	// FlushInterval 后台定时刷新的间隔，默认1秒
+	FlushInterval time.Duration
+	// Policy 缓冲区满时的处理策略，默认 Block
+	// Logger 在持有锁时写入各输出目标，作为 sink 使用时 Block 会让一个慢速输出拖住整个logger的日志，
+	// 不能接受时请使用 Drop
+	Policy OverflowPolicy
+	// OnError 后台写入出错时调用，默认输出到标准错误
+	OnError func(err error)
+}
+
+// AsyncWriter writes to an underlying writer from a background goroutine. Writes are queued in a
+// bounded ring buffer and written out in batches through a buffered writer, which is flushed
+// every FlushInterval, on Flush and on Close.
+//
+// Wrap a sink with it to keep slow outputs off the logging path:
+//
+//	w := log.NewAsyncWriter(file, log.AsyncOptions{Policy: log.Drop})
+//	log.AddSink(w, log.TraceLevel)
+//	defer log.Close()
+type AsyncWriter struct {
+	out     io.Writer
+	bw      *bufio.Writer
+	options AsyncOptions
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	ring    [][]byte
+	head    int
+	count   int
+	closed  bool
+
+	dropped uint64
+
+	wake     chan struct{}
+	flushReq chan chan error
+	closing  chan struct{}
+	stopped  chan struct{}
+}
+
+// NewAsyncWriter 创建异步写入器并启动后台写入goroutine
+func NewAsyncWriter(out io.Writer, options AsyncOptions) *AsyncWriter {
+	if options.BufferSize <= 0 {
+		options.BufferSize = defaultAsyncBufferSize
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = defaultAsyncFlushInterval
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to write to async log writer, %v\n", err)
+		}
+	}
+	w := &AsyncWriter{
+		out:      out,
+		bw:       bufio.NewWriter(out),
+		options:  options,
+		ring:     make([][]byte, options.BufferSize),
+		wake:     make(chan struct{}, 1),
+		flushReq: make(chan chan error),
+		closing:  make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// Write queues a copy of p. With the Drop policy a full buffer discards p without an error.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	w.mu.Lock()
+	for !w.closed && w.count == len(w.ring) {
+		if w.options.Policy == Drop {
+			w.mu.Unlock()
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		}
+		w.notFull.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		return 0, ErrWriterClosed
+	}
+	w.ring[(w.head+w.count)%len(w.ring)] = b
+	w.count++
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Dropped returns the number of writes discarded because the buffer was full.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush waits until everything queued so far is written to the underlying writer.
+func (w *AsyncWriter) Flush() error {
+	done := make(chan error, 1)
+	select {
+	case w.flushReq <- done:
+		return <-done
+	case <-w.stopped:
+		return nil
+	}
+}
+
+// Close flushes the queued writes and stops the background goroutine. Writes after Close return
+// ErrWriterClosed. The underlying writer is closed too when it implements io.Closer and is not
+// os.Stdout or os.Stderr.
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	close(w.closing)
+	<-w.stopped
+	err := w.bw.Flush()
+	if c, ok := w.out.(io.Closer); ok && !isStdStream(w.out) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// run 后台写入goroutine
+func (w *AsyncWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.options.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.wake:
+			w.drain()
+		case <-ticker.C:
+			w.drain()
+			w.flushBuffer()
+		case done := <-w.flushReq:
+			w.drain()
+			done <- w.flushBuffer()
+		case <-w.closing:
+			w.drain()
+			return
+		}
+	}
+}
+
+// drain 取出缓冲区中的所有日志并写入bufio
+func (w *AsyncWriter) drain() {
+	for {
+		w.mu.Lock()
+		if w.count == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch := make([][]byte, 0, w.count)
+		for ; w.count > 0; w.count-- {
+			batch = append(batch, w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+		}
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		for _, b := range batch {
+			if _, err := w.bw.Write(b); err != nil {
+				w.options.OnError(err)
+				// bufio.Writer 出错后会一直返回该错误，重建以便后续日志可以继续写入
+				w.bw = bufio.NewWriter(w.out)
+			}
+		}
+	}
+}
+
+// flushBuffer 将bufio中的内容写入底层writer
+func (w *AsyncWriter) flushBuffer() error {
+	err := w.bw.Flush()
+	if err != nil {
+		w.options.OnError(err)
+		w.bw = bufio.NewWriter(w.out)
+	}
+	return err
+}
+
+// isStdStream 判断是否为标准输出或标准错误，这两者不应该被关闭
+func isStdStream(out io.Writer) bool {
+	return out == os.Stdout || out == os.Stderr
+}
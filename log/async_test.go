@@ -0,0 +1,192 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer 并发安全的buffer
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// gateWriter 在gate关闭前阻塞写入，用于模拟慢速输出，第一次写入开始时关闭started
+type gateWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+	lockedBuffer
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+	return w.lockedBuffer.Write(p)
+}
+
+// bigLine 超过bufio缓冲区大小的日志，会直接写入底层writer
+var bigLine = strings.Repeat("x", 8192) + "\n"
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestAsyncWriter 测试异步写入保持顺序，Flush 后全部写出
+func TestAsyncWriter(t *testing.T) {
+	out := &lockedBuffer{}
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 4, FlushInterval: time.Hour})
+
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("line %d\n", i)
+		want.WriteString(line)
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil || out.String() != want.String() {
+		t.Fatalf("Flush 后应该按顺序写出所有日志: %q %v", out.String(), err)
+	}
+	if w.Dropped() != 0 {
+		t.Fatal("Block 模式不应该丢弃日志")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late")); err != ErrWriterClosed {
+		t.Fatalf("关闭后写入应该返回 ErrWriterClosed: %v", err)
+	}
+}
+
+// TestAsyncWriterDrop 测试缓冲区满时丢弃日志并计数
+func TestAsyncWriterDrop(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 2, Policy: Drop, FlushInterval: time.Hour})
+
+	// 第一条超过bufio的大小，被后台取出后直接阻塞在写入，之后两条填满缓冲区，其余被丢弃
+	_, _ = w.Write([]byte(bigLine))
+	<-out.started
+	for i := 1; i <= 5; i++ {
+		if _, err := w.Write([]byte(fmt.Sprintf("%d\n", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.Dropped() != 3 {
+		t.Fatalf("应该丢弃3条日志: %d", w.Dropped())
+	}
+
+	close(out.gate)
+	_ = w.Close()
+	if out.String() != bigLine+"1\n2\n" {
+		t.Fatalf("未丢弃的日志应该被写出: %q", out.String())
+	}
+}
+
+// TestAsyncWriterBlock 测试 Block 模式下缓冲区满时阻塞写入
+func TestAsyncWriterBlock(t *testing.T) {
+	out := newGateWriter()
+	w := NewAsyncWriter(out, AsyncOptions{BufferSize: 1, FlushInterval: time.Hour})
+	_, _ = w.Write([]byte(bigLine))
+	<-out.started
+	_, _ = w.Write([]byte("1\n"))
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("2\n"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("缓冲区满时写入应该阻塞")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(out.gate)
+	<-written
+	_ = w.Close()
+	if out.String() != bigLine+"1\n2\n" || w.Dropped() != 0 {
+		t.Fatalf("Block 模式不应该丢失日志: %q", out.String())
+	}
+}
+
+// TestAsyncWriterError 测试后台写入错误通过 OnError 报告
+func TestAsyncWriterError(t *testing.T) {
+	errs := make(chan error, 10)
+	w := NewAsyncWriter(errWriter{}, AsyncOptions{OnError: func(err error) { errs <- err }})
+	_, _ = w.Write([]byte("x\n"))
+	if err := w.Flush(); err == nil {
+		t.Fatal("Flush 应该返回写入错误")
+	}
+	if err := <-errs; err.Error() != "disk full" {
+		t.Fatalf("应该报告写入错误: %v", err)
+	}
+	_ = w.Close()
+}
+
+// TestLoggerWriteError 测试写入失败时报告错误而不是退出进程
+func TestLoggerWriteError(t *testing.T) {
+	var errs []error
+	logger := newLogger()
+	logger.Out = errWriter{}
+	logger.LogPath = t.TempDir() // 目录无法作为文件打开
+	logger.OnError = func(err error) { errs = append(errs, err) }
+	logger.ExitFunc = func(int) { t.Fatal("写入失败不应该退出进程") }
+
+	logger.Info("lost")
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "open log file") || !strings.Contains(errs[1].Error(), "disk full") {
+		t.Fatalf("应该报告打开文件与写入的错误: %v", errs)
+	}
+}
+
+// TestLoggerAsyncClose 测试日志文件保持打开，Close 写出异步sink并关闭文件
+func TestLoggerAsyncClose(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf)
+	out := &lockedBuffer{}
+	async := NewAsyncWriter(out, AsyncOptions{FlushInterval: time.Hour})
+	logger.AddSink(async, TraceLevel)
+
+	logger.Info("first")
+	file := logger.file
+	logger.Info("second")
+	if file == nil || logger.file != file {
+		t.Fatal("日志文件应该只打开一次")
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "[info]") != 2 || logger.file != nil {
+		t.Fatalf("Close 应该写出异步sink并关闭文件: %q", out.String())
+	}
+	data, _ := os.ReadFile(logger.LogPath)
+	if !strings.Contains(string(data), "first") || !strings.Contains(string(data), "second") {
+		t.Fatalf("日志文件内容不正确: %q", data)
+	}
+}
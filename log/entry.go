@@ -4,13 +4,25 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 )
 
+// ErrorKey defines the key when adding errors using WithError.
+var ErrorKey = "error"
+
+// Fields type, used to pass to `WithFields`.
+type Fields map[string]interface{}
+
 type Entry struct {
 	Logger *Logger
 
-	// Time at which the log entry was created
+	// Contains all the fields set by the user.
+	Data Fields
+
+	// Time at which the log entry was created, zero means the time of the log call
 	Time time.Time
 
 	// Level the log entry level
@@ -24,13 +36,20 @@ type Entry struct {
 
 	// err may contain a field formatting error
 	err string
+
+	// keys 字段按加入顺序排列的key
+	keys []string
+
+	// terminal 正在格式化的输出是否为终端，plain 表示为文件与sink格式化，不带颜色并使用完整时间
+	terminal bool
+	plain    bool
 }
 
 // NewEntry 节点构造函数
 func NewEntry(logger *Logger) *Entry {
 	return &Entry{
 		Logger:  logger,
-		Time:    time.Now(),
+		Data:    make(Fields, 6),
 		Level:   InfoLevel,
 		Message: "",
 		Context: context.Background(),
@@ -38,23 +57,138 @@ func NewEntry(logger *Logger) *Entry {
 	}
 }
 
-// Log 暴露的Log方法
+// Dup 复制一个entry，字段会被复制，修改副本的字段不会影响原entry
+func (entry *Entry) Dup() *Entry {
+	data := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	return &Entry{
+		Logger:  entry.Logger,
+		Data:    data,
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Context: entry.Context,
+		err:     entry.err,
+		keys:    append([]string(nil), entry.keys...),
+	}
+}
+
+// WithError adds an error as single field (using the key defined in ErrorKey) to the Entry.
+func (entry *Entry) WithError(err error) *Entry {
+	return entry.WithField(ErrorKey, err)
+}
+
+// WithContext adds a context to the Entry.
+func (entry *Entry) WithContext(ctx context.Context) *Entry {
+	dup := entry.Dup()
+	dup.Context = ctx
+	return dup
+}
+
+// WithField adds a single field to the Entry.
+func (entry *Entry) WithField(key string, value interface{}) *Entry {
+	return entry.WithFields(Fields{key: value})
+}
+
+// WithFields adds a map of fields to the Entry and returns a new Entry, the original is unchanged.
+// Fields keep the order in which they were added, a key that is added again keeps its position
+// and takes the new value. Keys within one call are added in sorted order.
+// Function values can not be logged and are reported instead.
+func (entry *Entry) WithFields(fields Fields) *Entry {
+	dup := entry.Dup()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fieldErrs []string
+	for _, k := range keys {
+		v := fields[k]
+		if t := reflect.TypeOf(v); t != nil && (t.Kind() == reflect.Func ||
+			t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Func) {
+			fieldErrs = append(fieldErrs, fmt.Sprintf("can not add field %q", k))
+			continue
+		}
+		if _, ok := dup.Data[k]; !ok {
+			dup.keys = append(dup.keys, k)
+		}
+		dup.Data[k] = v
+	}
+	if len(fieldErrs) > 0 {
+		if dup.err != "" {
+			fieldErrs = append([]string{dup.err}, fieldErrs...)
+		}
+		dup.err = strings.Join(fieldErrs, ", ")
+	}
+	return dup
+}
+
+// fieldKeys 返回字段的key，先按加入顺序，hook直接写入 Data 的字段排在最后并按key排序
+func (entry *Entry) fieldKeys() []string {
+	keys := make([]string, 0, len(entry.Data))
+	known := make(map[string]bool, len(entry.keys))
+	for _, k := range entry.keys {
+		if _, ok := entry.Data[k]; ok && !known[k] {
+			keys = append(keys, k)
+			known[k] = true
+		}
+	}
+	extra := len(keys)
+	for k := range entry.Data {
+		if !known[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[extra:])
+	return keys
+}
+
+// Log 暴露的Log方法，级别没有开启时不会记录
 func (entry *Entry) Log(level Level, args ...interface{}) {
-	entry.log(level, fmt.Sprint(args...))
+	if entry.Logger.IsLevelEnabled(level) {
+		entry.log(level, fmt.Sprint(args...))
+	}
 }
 
-// log 内部处理函数
+// log 内部处理函数，在副本上记录，同一个entry可以被多个goroutine同时使用
 func (entry *Entry) log(level Level, msg string) {
-
-	if entry.Time.IsZero() {
-		entry.Time = time.Now()
+	newEntry := entry.Dup()
+	if newEntry.Time.IsZero() {
+		newEntry.Time = time.Now()
 	}
-	entry.Level = level
-	entry.Message = msg
+	newEntry.Level = level
+	newEntry.Message = msg
+
+	newEntry.fireHooks()
+
+	newEntry.write()
+}
+
+func (entry *Entry) Trace(args ...interface{}) {
+	entry.Log(TraceLevel, args...)
+}
+
+func (entry *Entry) Debug(args ...interface{}) {
+	entry.Log(DebugLevel, args...)
+}
+
+func (entry *Entry) Info(args ...interface{}) {
+	entry.Log(InfoLevel, args...)
+}
+
+func (entry *Entry) Warn(args ...interface{}) {
+	entry.Log(WarnLevel, args...)
+}
 
-	entry.fireHooks()
+func (entry *Entry) Error(args ...interface{}) {
+	entry.Log(ErrorLevel, args...)
+}
 
-	entry.write()
+func (entry *Entry) Panic(args ...interface{}) {
+	entry.Log(PanicLevel, args...)
+	entry.Logger.Exit(1)
 }
 
 // fireHooks run all the hooks
@@ -73,42 +207,54 @@ func (entry *Entry) fireHooks() {
 	}
 }
 
-// write 写入方法
+// write 写入方法，写入出错时通过 Logger.OnError 报告，不会退出进程
 func (entry *Entry) write() {
+	logger := entry.Logger
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
 
-	log := entry.formatter()
-
-	entry.Logger.mu.Lock()
-	defer entry.Logger.mu.Unlock()
-
-	// 将日志输入到指定文件路径
-	file, err := os.OpenFile(entry.Logger.LogPath, os.O_WRONLY, 0644)
-	if err != nil && os.IsNotExist(err) {
-		file, err = os.Create(entry.Logger.LogPath)
+	formatter := logger.Formatter
+	if formatter == nil {
+		formatter = defaultFormatter
+	}
+	entry.terminal = logger.outIsTerminal()
+	serialized, err := formatter.Format(entry)
+	if err != nil {
+		logger.reportError(fmt.Errorf("failed to format entry: %w", err))
+		return
+	}
+	// 颜色与相对时间只适用于终端，Out 为终端或强制颜色时，文件与其它输出目标按非终端重新格式化
+	log := serialized
+	if entry.terminal || forcesColors(formatter) {
+		entry.terminal, entry.plain = false, true
+		log, err = formatter.Format(entry)
+		entry.plain = false
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "fail open local log file")
-			os.Exit(1)
+			logger.reportError(fmt.Errorf("failed to format entry: %w", err))
+			return
 		}
 	}
-	defer file.Close()
 
-	// file 类型实现了io.Writer
-	n, _ := file.Seek(0, 2)
-	_, fErr := file.WriteAt(log, n)
-	if fErr != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "fail write log to local file")
-		os.Exit(1)
+	if file, err := logger.logFile(); err != nil {
+		logger.reportError(err)
+	} else if file != nil {
+		if _, err = file.Write(log); err != nil {
+			logger.reportError(fmt.Errorf("failed to write to log file: %w", err))
+		}
 	}
 
-	if _, err = entry.Logger.Out.Write(log); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Failed to write to log, #{err}\n")
+	if logger.Out != nil {
+		if _, err = logger.Out.Write(serialized); err != nil {
+			logger.reportError(fmt.Errorf("failed to write to log output: %w", err))
+		}
 	}
-}
 
-// formatter 日志内容格式化函数
-func (entry *Entry) formatter() []byte {
-	timestamp := "[" + time.Now().Format("2006-01-02 15:04:05") + "]"
-	level := "[" + entry.Level.String() + "] "
-	msg := entry.Message
-	return []byte(timestamp + level + msg + "\n")
+	for _, s := range logger.sinks {
+		if entry.Level > s.level {
+			continue
+		}
+		if _, err = s.out.Write(log); err != nil {
+			logger.reportError(fmt.Errorf("failed to write to log sink: %w", err))
+		}
+	}
 }
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fieldsHook 记录hook看到的字段
+type fieldsHook struct {
+	data Fields
+	ctx  context.Context
+}
+
+func (h *fieldsHook) Levels() []Level {
+	return []Level{InfoLevel}
+}
+
+func (h *fieldsHook) Fire(entry *Entry) error {
+	h.data = entry.Data
+	h.ctx = entry.Context
+	entry.Data["hooked"] = true
+	return nil
+}
+
+type ctxKey struct{}
+
+// TestWithFields 测试字段按加入顺序合并与输出，且派生entry不影响原entry
+func TestWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf)
+
+	base := logger.WithField("service", "vgo")
+	request := base.WithFields(Fields{"path": "/user", "id": 7}).WithField("service", "api")
+	request.WithError(errors.New("not found")).Warn("request failed")
+
+	line := buf.String()
+	if !strings.HasSuffix(line, `[warning] request failed service=api id=7 path=/user error="not found"`+"\n") {
+		t.Fatalf("字段输出不正确: %q", line)
+	}
+	if len(base.Data) != 1 || base.Data["service"] != "vgo" {
+		t.Fatalf("派生entry不应该修改原entry: %v", base.Data)
+	}
+
+	buf.Reset()
+	base.WithField("fn", func() {}).Info("func field")
+	if !strings.Contains(buf.String(), `service=vgo log_error="can not add field \"fn\""`) {
+		t.Fatalf("函数字段应该被拒绝: %q", buf.String())
+	}
+}
+
+// TestHookFields 测试hook可以看到字段和context
+func TestHookFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf)
+	hook := &fieldsHook{}
+	logger.AddHook(hook)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace-1")
+	logger.WithContext(ctx).WithField("user", "tom").Info("login")
+
+	if hook.data["user"] != "tom" || hook.ctx.Value(ctxKey{}) != "trace-1" {
+		t.Fatalf("hook应该看到字段和context: %v", hook.data)
+	}
+	if !strings.HasSuffix(buf.String(), "login user=tom hooked=true\n") {
+		t.Fatalf("hook添加的字段应该输出: %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.Info("plain")
+	if !strings.HasSuffix(buf.String(), "[info] plain hooked=true\n") {
+		t.Fatalf("对象池中的entry应该被清空: %q", buf.String())
+	}
+}
+
+// TestExportedWithFields 测试标准logger的字段方法
+func TestExportedWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+
+	WithFields(Fields{"a": 1}).WithField("b", "x y").Info("exported")
+	WithError(errors.New("e")).WithContext(context.Background()).Error("failed")
+	if !strings.Contains(buf.String(), `exported a=1 b="x y"`) || !strings.Contains(buf.String(), "failed error=e") {
+		t.Fatalf("标准logger字段输出不正确: %q", buf.String())
+	}
+}
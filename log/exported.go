@@ -1,12 +1,43 @@
 package log
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
 var (
 	// std is the name of the standard logger in stdlib `log`
 	std = newLogger()
 )
 
+// WithError creates an entry from the standard logger and adds an error to it, using the value defined in ErrorKey as key.
+func WithError(err error) *Entry {
+	return std.WithError(err)
+}
+
+// WithContext creates an entry from the standard logger and adds a context to it.
+func WithContext(ctx context.Context) *Entry {
+	return std.WithContext(ctx)
+}
+
+// WithField creates an entry from the standard logger and adds a field to
+// it. If you want multiple fields, use `WithFields`.
+//
+// Note that it doesn't log until you call Trace, Debug, Info, Warn, Error
+// or Panic on the Entry it returns.
+func WithField(key string, value interface{}) *Entry {
+	return std.WithField(key, value)
+}
+
+// WithFields creates an entry from the standard logger and adds multiple
+// fields to it in one merge, in sorted key order.
+//
+// Note that it doesn't log until you call Trace, Debug, Info, Warn, Error
+// or Panic on the Entry it returns.
+func WithFields(fields Fields) *Entry {
+	return std.WithFields(fields)
+}
+
 // Trace logs a message at level Trace on the standard logger.
 func Trace(args ...interface{}) {
 	std.Trace(args...)
@@ -42,12 +73,53 @@ func SetLogPath(logPath string) {
 	std.SetLogPath(logPath)
 }
 
+// SetRotatingLogFile makes the standard logger write its log file through a RotatingFile.
+func SetRotatingLogFile(options RotateOptions) error {
+	return std.SetRotatingLogFile(options)
+}
+
 // SetOutput set the file output
 func SetOutput(output io.Writer) {
 	std.SetOutput(output)
 }
 
-////
-//func AddHook() {
-//
-//}
+// SetFormatter sets the standard logger formatter.
+func SetFormatter(formatter Formatter) {
+	std.SetFormatter(formatter)
+}
+
+// AddSink adds an output of the standard logger that only receives entries at or above level.
+// Outputs are written while the logger is locked, see Logger.AddSink.
+func AddSink(output io.Writer, level Level) {
+	std.AddSink(output, level)
+}
+
+// SetLevel sets the standard logger level.
+func SetLevel(level Level) {
+	std.SetLevel(level)
+}
+
+// GetLevel returns the standard logger level.
+func GetLevel() Level {
+	return std.GetLevel()
+}
+
+// IsLevelEnabled checks if the log level of the standard logger is greater than the level param
+func IsLevelEnabled(level Level) bool {
+	return std.IsLevelEnabled(level)
+}
+
+// AddHook adds a hook to the standard logger hooks.
+func AddHook(hook Hook) {
+	std.AddHook(hook)
+}
+
+// Flush writes out the entries buffered by the outputs of the standard logger.
+func Flush() error {
+	return std.Flush()
+}
+
+// Close flushes the standard logger and closes its log file and closable outputs, call it before exiting.
+func Close() error {
+	return std.Close()
+}
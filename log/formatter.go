@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Default key names for the default fields
+const (
+	defaultTimestampFormat = time.RFC3339
+	FieldKeyMsg            = "msg"
+	FieldKeyLevel          = "level"
+	FieldKeyTime           = "time"
+	FieldKeyLogError       = "log_error"
+)
+
+// The Formatter interface is used to implement a custom Formatter. It takes an
+// `Entry`, whose default fields are in its own fields rather than in `Data`:
+//
+// * `entry.Message`. The message passed from Info, Warn, Error ..
+// * `entry.Time`. The time the entry was logged at.
+// * `entry.Level`. The level the entry was logged at.
+//
+// `entry.Data` only holds the fields added with `WithField`, `WithFields` or
+// `WithError` and by hooks. Format is expected to return an array of bytes which
+// are then written to `logger.Out`, the log file and the sinks.
+type Formatter interface {
+	Format(*Entry) ([]byte, error)
+}
+
+type fieldKey string
+
+// FieldMap allows customization of the key names for default fields.
+type FieldMap map[fieldKey]string
+
+func (f FieldMap) resolve(key fieldKey) string {
+	if k, ok := f[key]; ok {
+		return k
+	}
+	return string(key)
+}
+
+// prefixFieldClashes 字段与默认字段同名时加上 "fields." 前缀，避免被覆盖
+func prefixFieldClashes(data Fields, fieldMap FieldMap) {
+	for _, key := range []fieldKey{FieldKeyTime, FieldKeyMsg, FieldKeyLevel, FieldKeyLogError} {
+		k := fieldMap.resolve(key)
+		if v, ok := data[k]; ok {
+			data["fields."+k] = v
+			delete(data, k)
+		}
+	}
+}
+
+// sortedKeys 返回字段的key，sortFields 为false时按加入顺序
+func (entry *Entry) sortedKeys(sortFields bool) []string {
+	keys := entry.fieldKeys()
+	if sortFields {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// fieldValue 格式化字段的值，包含空白、引号或等号时加上引号
+func fieldValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if needsQuoting(s) {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, ch := range s {
+		if ch <= ' ' || ch == '"' || ch == '=' || ch == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfTerminal 判断输出是否为终端
+func checkIfTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,157 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenEntries 用于格式化测试的固定entry
+func goldenEntries() []*Entry {
+	logger := newLogger()
+	at := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	plain := NewEntry(logger)
+	plain.Time, plain.Level, plain.Message = at, InfoLevel, "server started"
+
+	fields := NewEntry(logger).WithField("path", "/user").WithFields(Fields{
+		"id":  7,
+		"msg": "clash",
+		"tag": "a b=c",
+	}).WithError(errors.New("not found"))
+	fields.Time, fields.Level, fields.Message = at, WarnLevel, "request \"failed\""
+
+	bad := NewEntry(logger).WithField("fn", func() {}).WithField("html", "<b>")
+	bad.Time, bad.Level, bad.Message = at, ErrorLevel, ""
+
+	return []*Entry{plain, fields, bad}
+}
+
+// assertGolden 比较格式化结果与 testdata 中的golden文件，使用 -update 重新生成
+func assertGolden(t *testing.T, name string, formatters ...Formatter) {
+	var buf bytes.Buffer
+	for _, formatter := range formatters {
+		for _, entry := range goldenEntries() {
+			b, err := formatter.Format(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf.Write(b)
+		}
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("%s 输出与golden文件不一致:\n%s\n期望:\n%s", name, buf.Bytes(), want)
+	}
+}
+
+// TestTextFormatter 测试文本格式，包括时间格式、字段排序与终端颜色
+func TestTextFormatter(t *testing.T) {
+	assertGolden(t, "text",
+		&TextFormatter{},
+		&TextFormatter{TimestampFormat: time.RFC3339, SortFields: true},
+		&TextFormatter{DisableTimestamp: true},
+		&TextFormatter{ForceColors: true, FullTimestamp: true},
+	)
+}
+
+// TestJSONFormatter 测试JSON格式，包括字段重命名、嵌套字段与缩进
+func TestJSONFormatter(t *testing.T) {
+	assertGolden(t, "json",
+		&JSONFormatter{},
+		&JSONFormatter{FieldMap: FieldMap{FieldKeyTime: "@timestamp", FieldKeyMsg: "message"}, DisableHTMLEscape: true},
+		&JSONFormatter{DataKey: "fields", DisableTimestamp: true},
+		&JSONFormatter{PrettyPrint: true, TimestampFormat: time.Kitchen},
+	)
+}
+
+// TestLogfmtFormatter 测试logfmt格式
+func TestLogfmtFormatter(t *testing.T) {
+	assertGolden(t, "logfmt",
+		&LogfmtFormatter{},
+		&LogfmtFormatter{SortFields: true, DisableTimestamp: true, FieldMap: FieldMap{FieldKeyLevel: "severity"}},
+	)
+}
+
+// TestFormatterOutput 测试Logger使用设置的Formatter，写入文件时去掉颜色
+func TestFormatterOutput(t *testing.T) {
+	var out, sink bytes.Buffer
+	logger := newTestLogger(t, &out)
+	logger.AddSink(&sink, InfoLevel)
+	logger.SetFormatter(&TextFormatter{ForceColors: true})
+
+	logger.WithField("user", "tom").Info("login")
+	if !bytes.Contains(out.Bytes(), []byte("\x1b[36m[info]\x1b[0m login")) {
+		t.Fatalf("Out 应该输出颜色: %q", out.String())
+	}
+	data, _ := os.ReadFile(logger.LogPath)
+	if bytes.Contains(data, []byte("\x1b[")) || !bytes.HasSuffix(sink.Bytes(), []byte("[info] login user=tom\n")) {
+		t.Fatalf("文件与sink不应该包含颜色: %q %q", data, sink.String())
+	}
+
+	out.Reset()
+	logger.SetFormatter(&JSONFormatter{DisableTimestamp: true})
+	logger.WithError(errors.New("e")).Error("failed")
+	if out.String() != `{"error":"e","level":"error","msg":"failed"}`+"\n" {
+		t.Fatalf("JSON 输出不正确: %q", out.String())
+	}
+}
+
+// TestFormatterFileTimestamp 测试终端输出使用相对时间时，文件与sink仍然写入完整时间
+func TestFormatterFileTimestamp(t *testing.T) {
+	var out, sink bytes.Buffer
+	logger := newTestLogger(t, &out)
+	logger.AddSink(&sink, InfoLevel)
+	logger.SetFormatter(&TextFormatter{ForceColors: true})
+
+	entry := NewEntry(logger)
+	entry.Time = time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	entry.Info("login")
+	if bytes.HasPrefix(out.Bytes(), []byte("[2021-03-04 05:06:07]")) {
+		t.Fatalf("Out 应该使用相对时间: %q", out.String())
+	}
+	want := "[2021-03-04 05:06:07][info] login\n"
+	data, _ := os.ReadFile(logger.LogPath)
+	if string(data) != want || sink.String() != want {
+		t.Fatalf("文件与sink应该使用完整时间: %q %q", data, sink.String())
+	}
+}
+
+// TestFormatterEscapeInMessage 测试非终端输出时消息中的控制序列原样写入，不会触发重新格式化
+func TestFormatterEscapeInMessage(t *testing.T) {
+	var out, sink bytes.Buffer
+	logger := newTestLogger(t, &out)
+	logger.AddSink(&sink, InfoLevel)
+
+	entry := NewEntry(logger)
+	entry.Time = time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)
+	entry.Info("raw \x1b[31mred\x1b[0m")
+	want := "[2021-03-04 05:06:07][info] raw \x1b[31mred\x1b[0m\n"
+	data, _ := os.ReadFile(logger.LogPath)
+	if out.String() != want || string(data) != want || sink.String() != want {
+		t.Fatalf("消息内容不应该被修改: %q %q %q", out.String(), data, sink.String())
+	}
+
+	// 默认格式在终端中同样输出完整时间
+	entry.Level, entry.Message, entry.terminal = InfoLevel, "tty", true
+	b, _ := newLogger().Formatter.Format(entry)
+	if !bytes.HasPrefix(b, []byte("[2021-03-04 05:06:07]\x1b[")) {
+		t.Fatalf("默认格式在终端中应该输出完整时间: %q", b)
+	}
+}
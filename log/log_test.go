@@ -1,6 +1,33 @@
 package log
 
-import "testing"
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestMain 将标准logger的日志文件写入临时目录
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "vgo-log")
+	if err != nil {
+		panic(err)
+	}
+	SetLogPath(filepath.Join(dir, "log.txt"))
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestLogger 创建输出到buf的logger，日志文件写入临时目录
+func newTestLogger(t *testing.T, buf *bytes.Buffer) *Logger {
+	logger := newLogger()
+	logger.Out = buf
+	logger.LogPath = filepath.Join(t.TempDir(), "log.txt")
+	return logger
+}
 
 func TestTrace(t *testing.T) {
 	Trace("trace testing")
@@ -23,5 +50,85 @@ func TestError(t *testing.T) {
 }
 
 func TestPanic(t *testing.T) {
+	exitCode := -1
+	std.ExitFunc = func(code int) { exitCode = code }
+	defer func() { std.ExitFunc = os.Exit }()
+
 	Panic("panic testing")
-}
\ No newline at end of file
+	if exitCode != 1 {
+		t.Fatalf("Panic 应该以1退出: %d", exitCode)
+	}
+}
+
+// TestLevel 测试低于 Logger.Level 的日志不会输出
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf)
+
+	logger.Trace("trace")
+	logger.Debug("debug")
+	logger.Info("info")
+	if strings.Contains(buf.String(), "trace") || strings.Contains(buf.String(), "debug") || !strings.Contains(buf.String(), "[info] info") {
+		t.Fatalf("默认只输出info及以上级别: %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.SetLevel(TraceLevel)
+	if logger.GetLevel() != TraceLevel || !logger.IsLevelEnabled(DebugLevel) {
+		t.Fatal("SetLevel 没有生效")
+	}
+	logger.Trace("trace")
+	if !strings.Contains(buf.String(), "[trace] trace") {
+		t.Fatalf("开启trace级别后应该输出: %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.SetLevel(ErrorLevel)
+	logger.Warn("warn")
+	NewEntry(logger).Log(InfoLevel, "entry")
+	logger.Error("error")
+	if !strings.HasSuffix(buf.String(), "[error] error\n") || strings.Contains(buf.String(), "warn") || strings.Contains(buf.String(), "entry") {
+		t.Fatalf("error级别下只输出error及以上级别: %q", buf.String())
+	}
+}
+
+// TestLevelConcurrent 测试并发修改与读取级别
+func TestLevelConcurrent(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(t, &buf)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			logger.SetLevel(DebugLevel)
+		}()
+		go func() {
+			defer wg.Done()
+			logger.IsLevelEnabled(DebugLevel)
+		}()
+	}
+	wg.Wait()
+}
+
+// TestSink 测试输出目标各自的最低级别
+func TestSink(t *testing.T) {
+	var all, errs bytes.Buffer
+	logger := newTestLogger(t, &all)
+	logger.AddSink(&errs, ErrorLevel)
+
+	logger.Info("started")
+	logger.Error("failed")
+
+	if !strings.Contains(all.String(), "started") || !strings.Contains(all.String(), "failed") {
+		t.Fatalf("Out 应该输出所有开启的级别: %q", all.String())
+	}
+	if strings.Contains(errs.String(), "started") || !strings.Contains(errs.String(), "[error] failed") {
+		t.Fatalf("sink 只应该输出error及以上级别: %q", errs.String())
+	}
+
+	data, err := os.ReadFile(logger.LogPath)
+	if err != nil || !strings.Contains(string(data), "started") {
+		t.Fatalf("日志文件应该输出所有开启的级别: %q %v", data, err)
+	}
+}
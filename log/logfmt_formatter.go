@@ -0,0 +1,63 @@
+package log
+
+import (
+	"bytes"
+)
+
+// LogfmtFormatter formats logs as logfmt key=value pairs, e.g.
+//
+//	time=2006-01-02T15:04:05Z level=info msg="user login" user=tom
+type LogfmtFormatter struct {
+	// TimestampFormat sets the format used for timestamps, defaults to time.RFC3339.
+	TimestampFormat string
+
+	// DisableTimestamp allows disabling automatic timestamps in output
+	DisableTimestamp bool
+
+	// SortFields sorts the fields by key, by default fields keep the order in which they were added.
+	SortFields bool
+
+	// FieldMap allows users to customize the names of keys for default fields.
+	FieldMap FieldMap
+}
+
+// Format renders a single log entry
+func (f *LogfmtFormatter) Format(entry *Entry) ([]byte, error) {
+	b := &bytes.Buffer{}
+	if !f.DisableTimestamp {
+		timestampFormat := f.TimestampFormat
+		if timestampFormat == "" {
+			timestampFormat = defaultTimestampFormat
+		}
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyTime), entry.Time.Format(timestampFormat))
+	}
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLevel), entry.Level.String())
+	f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyMsg), entry.Message)
+
+	data := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+	prefixFieldClashes(data, f.FieldMap)
+	for _, k := range entry.sortedKeys(f.SortFields) {
+		key := k
+		if _, ok := data[key]; !ok {
+			key = "fields." + k
+		}
+		f.appendKeyValue(b, key, data[key])
+	}
+	if entry.err != "" {
+		f.appendKeyValue(b, f.FieldMap.resolve(FieldKeyLogError), entry.err)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func (f *LogfmtFormatter) appendKeyValue(b *bytes.Buffer, key string, value interface{}) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(fieldValue(value))
+}
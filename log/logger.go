@@ -1,11 +1,14 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // 各日志级别
@@ -28,6 +31,11 @@ type Logger struct {
 	// 一般将日志输出到一个文件，也可以输出到Kafka
 	Out io.Writer
 
+	// All log entries pass through the formatter before logged to Out. The
+	// included formatters are `TextFormatter`, `JSONFormatter` and `LogfmtFormatter`
+	// for which TextFormatter is the default.
+	Formatter Formatter
+
 	// Hooks for the logger instance. These allow firing events based on logging
 	// levels and log entries. For example, to send errors to an error tracking
 	// service, log to StatsD or dump the core on fatal errors.
@@ -36,7 +44,8 @@ type Logger struct {
 	// Flag for whether to log caller info (off by default)
 	ReportCaller bool
 
-	// The logging level the logger should log at.
+	// The logging level the logger should log at. Entries above this level are discarded
+	// before they are built, use SetLevel to change it while logging.
 	Level Level
 	// Used to sync writing to the log. Locking is enabled by Default
 	mu MutexWrap
@@ -44,8 +53,25 @@ type Logger struct {
 	entryPool sync.Pool
 	// Function to exit the application, default to `os.Exit()`
 	ExitFunc exitFunc
-	// log file path
+	// log file path, the file is opened on the first entry and kept open. An empty path disables the log file.
 	LogPath string
+	// OnError is called when an entry can not be formatted or written, by default the error is
+	// printed to os.Stderr. It is called with the logger locked and must not log through the same logger.
+	OnError func(err error)
+	// file 已经打开的日志文件，filePath 为其路径，LogPath 变化时重新打开
+	file     io.WriteCloser
+	filePath string
+	// sinks 额外的输出目标，各自带有最低级别，通过 AddSink 添加
+	sinks []sink
+	// termFile 上次检查过的 Out，termIsTerminal 为检查结果，Out 改变时重新检查
+	termFile       *os.File
+	termIsTerminal bool
+}
+
+// sink 带有独立级别的输出目标
+type sink struct {
+	out   io.Writer
+	level Level
 }
 
 type MutexWrap struct {
@@ -71,10 +97,15 @@ func (mw *MutexWrap) Disable() {
 
 type exitFunc func(int)
 
+// defaultFormatter 没有设置 Formatter 时使用的格式，终端中同样输出完整时间
+var defaultFormatter = &TextFormatter{FullTimestamp: true}
+
 // NewLogger 建议创建一个全局实例log，也可以自定义通过 &logs.Logger{}自定义生成日志对象
 func newLogger() *Logger {
 	return &Logger{
 		Out:          os.Stdout,
+		Formatter:    &TextFormatter{FullTimestamp: true},
+		Hooks:        make(LevelHooks),
 		Level:        InfoLevel,
 		ExitFunc:     os.Exit,
 		ReportCaller: false,
@@ -82,6 +113,18 @@ func newLogger() *Logger {
 	}
 }
 
+// outIsTerminal 判断 Out 是否为终端，结果按logger缓存，调用时需持有锁
+func (logger *Logger) outIsTerminal() bool {
+	f, ok := logger.Out.(*os.File)
+	if !ok {
+		return false
+	}
+	if f != logger.termFile {
+		logger.termFile, logger.termIsTerminal = f, checkIfTerminal(f)
+	}
+	return logger.termIsTerminal
+}
+
 // newEntry 这里使用池来缓存对象，避免项目大量重复地创建许多对象。
 func (logger *Logger) newEntry() *Entry {
 	entry, ok := logger.entryPool.Get().(*Entry)
@@ -91,11 +134,49 @@ func (logger *Logger) newEntry() *Entry {
 	return NewEntry(logger)
 }
 
-// releaseEntry 将entry对象放回对象池
+// releaseEntry 清空entry后放回对象池
 func (logger *Logger) releaseEntry(entry *Entry) {
+	for k := range entry.Data {
+		delete(entry.Data, k)
+	}
+	entry.keys = entry.keys[:0]
+	entry.err = ""
+	entry.Time = time.Time{}
+	entry.Context = context.Background()
 	logger.entryPool.Put(entry)
 }
 
+// WithField allocates a new entry and adds a field to it.
+// Debug, Info, Warn, Error or Panic must be then applied to
+// this new returned entry.
+// If you want multiple fields, use `WithFields`.
+func (logger *Logger) WithField(key string, value interface{}) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.WithField(key, value)
+}
+
+// WithFields creates an entry with the given fields, merged in one pass in sorted key order.
+func (logger *Logger) WithFields(fields Fields) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.WithFields(fields)
+}
+
+// WithError creates an entry with err as its only field, using ErrorKey as the key.
+func (logger *Logger) WithError(err error) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.WithError(err)
+}
+
+// WithContext add a context to the log entry.
+func (logger *Logger) WithContext(ctx context.Context) *Entry {
+	entry := logger.newEntry()
+	defer logger.releaseEntry(entry)
+	return entry.WithContext(ctx)
+}
+
 // SetOutput sets the logger output.
 func (logger *Logger) SetOutput(output io.Writer) {
 	logger.mu.Lock()
@@ -103,13 +184,118 @@ func (logger *Logger) SetOutput(output io.Writer) {
 	logger.Out = output
 }
 
-// SetLogPath 设置日志输出文件路径
+// SetFormatter sets the logger formatter.
+func (logger *Logger) SetFormatter(formatter Formatter) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.Formatter = formatter
+}
+
+// SetLogPath 设置日志输出文件路径，之前打开的日志文件会被关闭
 func (logger *Logger) SetLogPath(logPath string) {
 	logger.mu.Lock()
 	defer logger.mu.Unlock()
+	logger.closeLogFile()
 	logger.LogPath = logPath
 }
 
+// SetRotatingLogFile 使用按大小或时间切割的文件作为日志文件，替换 LogPath 对应的文件，
+// LogPath 会被设置为 options.Filename
+func (logger *Logger) SetRotatingLogFile(options RotateOptions) error {
+	file, err := NewRotatingFile(options)
+	if err != nil {
+		return err
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.closeLogFile()
+	logger.LogPath = options.Filename
+	logger.file, logger.filePath = file, options.Filename
+	return nil
+}
+
+// logFile 返回打开的日志文件，首次写入或 LogPath 变化时以追加模式打开，调用方需要持有锁
+func (logger *Logger) logFile() (io.Writer, error) {
+	if logger.file != nil && logger.filePath == logger.LogPath {
+		return logger.file, nil
+	}
+	logger.closeLogFile()
+	if logger.LogPath == "" {
+		return nil, nil
+	}
+	file, err := os.OpenFile(logger.LogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	logger.file, logger.filePath = file, logger.LogPath
+	return file, nil
+}
+
+// closeLogFile 关闭已经打开的日志文件，调用方需要持有锁
+func (logger *Logger) closeLogFile() error {
+	if logger.file == nil {
+		return nil
+	}
+	err := logger.file.Close()
+	logger.file, logger.filePath = nil, ""
+	return err
+}
+
+// reportError 报告格式化或写入日志时的错误，调用方需要持有锁
+func (logger *Logger) reportError(err error) {
+	if logger.OnError != nil {
+		logger.OnError(err)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+}
+
+// Flush 将异步输出目标中缓冲的日志写出，例如 AsyncWriter
+func (logger *Logger) Flush() error {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	var firstErr error
+	for _, out := range logger.outputs() {
+		if f, ok := out.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Close 在程序退出前调用，写出所有缓冲的日志并关闭日志文件。
+// Out 与 sink 中实现了 io.Closer 的输出目标也会被关闭，os.Stdout 与 os.Stderr 除外。
+func (logger *Logger) Close() error {
+	firstErr := logger.Flush()
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	for _, out := range logger.outputs() {
+		if c, ok := out.(io.Closer); ok && !isStdStream(out) {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if err := logger.closeLogFile(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// outputs 返回 Out 与所有sink，调用方需要持有锁
+func (logger *Logger) outputs() []io.Writer {
+	outputs := make([]io.Writer, 0, len(logger.sinks)+1)
+	if logger.Out != nil {
+		outputs = append(outputs, logger.Out)
+	}
+	for _, s := range logger.sinks {
+		outputs = append(outputs, s.out)
+	}
+	return outputs
+}
+
 // AddHook adds a hook to the logger hooks.
 func (logger *Logger) AddHook(hook Hook) {
 	logger.mu.Lock()
@@ -117,7 +303,39 @@ func (logger *Logger) AddHook(hook Hook) {
 	logger.Hooks.Add(hook)
 }
 
+// AddSink 添加一个输出目标，只有级别不低于 level 的日志才会写入，例如将错误日志单独写入一个文件：
+//
+//	logger.AddSink(errorFile, log.ErrorLevel)
+//
+// Logger.Level 对所有输出目标生效，低于 Logger.Level 的日志不会写入任何输出目标。
+// 所有输出目标在logger的锁内依次写入，慢速的输出会阻塞所有日志调用；
+// 使用 AsyncWriter 包装时，它的 Block 策略在缓冲区满后同样会阻塞，需要保证日志调用不被阻塞时使用 Drop。
+func (logger *Logger) AddSink(out io.Writer, level Level) {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	logger.sinks = append(logger.sinks, sink{out: out, level: level})
+}
+
+// SetLevel sets the logger level.
+func (logger *Logger) SetLevel(level Level) {
+	atomic.StoreUint32((*uint32)(&logger.Level), uint32(level))
+}
+
+// GetLevel returns the logger level.
+func (logger *Logger) GetLevel() Level {
+	return Level(atomic.LoadUint32((*uint32)(&logger.Level)))
+}
+
+// IsLevelEnabled checks if the log level of the logger is greater than the level param
+func (logger *Logger) IsLevelEnabled(level Level) bool {
+	return logger.GetLevel() >= level
+}
+
+// Log 记录一条日志，级别没有开启时直接返回，不会创建entry
 func (logger *Logger) Log(level Level, args ...interface{}) {
+	if !logger.IsLevelEnabled(level) {
+		return
+	}
 	entry := logger.newEntry()
 	entry.Log(level, args...)
 	logger.releaseEntry(entry)
@@ -149,7 +367,8 @@ func (logger *Logger) Panic(args ...interface{}) {
 }
 
 func (logger *Logger) Exit(code int) {
-	//runHandlers()
+	// 退出前写出异步输出目标中缓冲的日志
+	_ = logger.Flush()
 	if logger.ExitFunc == nil {
 		logger.ExitFunc = os.Exit
 	}
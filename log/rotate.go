@@ -0,0 +1,477 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat 备份文件名中的时间格式，例如 app-2021-03-04T05-06-07.000.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// RotateInterval 按时间切割日志文件的周期
+type RotateInterval int
+
+const (
+	// RotateNone 不按时间切割
+	RotateNone RotateInterval = iota
+	// RotateHourly 每个整点切割
+	RotateHourly
+	// RotateDaily 每天零点切割
+	RotateDaily
+)
+
+// RotateOptions RotatingFile 的配置，MaxSize 与 Interval 可以同时使用，满足任意一个条件即切割
+type RotateOptions struct {
+	// Filename 当前日志文件的路径，切割后的备份文件与它放在同一目录
+	Filename string
+	// MaxSize 日志文件的最大字节数，写入后超出时先切割，0表示不按大小切割
+	MaxSize int64
+	// Interval 按小时或按天切割，时间按本地时区计算
+	Interval RotateInterval
+	// MaxBackups 最多保留的备份文件数，0表示不限制
+	MaxBackups int
+	// MaxAge 备份文件的最长保留时间，0表示不限制
+	MaxAge time.Duration
+	// Compress 是否使用gzip压缩备份文件
+	Compress bool
+	// ReopenOnSIGHUP 收到SIGHUP信号时重新打开日志文件，配合logrotate的move方式使用
+	ReopenOnSIGHUP bool
+	// OnError 后台清理、压缩备份或重新打开文件出错时调用，默认输出到标准错误
+	OnError func(err error)
+}
+
+// RotatingFile is an io.WriteCloser that writes to Filename and rotates it by size and/or
+// time, keeping a bounded number of optionally gzipped backups. Use it as a sink:
+//
+//	rf, err := log.NewRotatingFile(log.RotateOptions{
+//		Filename:   "./logs/app.log",
+//		MaxSize:    100 << 20,
+//		Interval:   log.RotateDaily,
+//		MaxBackups: 7,
+//		Compress:   true,
+//	})
+//	log.AddSink(rf, log.InfoLevel)
+type RotatingFile struct {
+	options RotateOptions
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time // 下一次按时间切割的时间点
+	closed       bool
+
+	// now 当前时间，测试中替换为假时钟
+	now func() time.Time
+
+	millCh   chan struct{}
+	signalCh chan os.Signal
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewRotatingFile 创建按大小或时间切割的日志文件，文件在第一次写入时打开
+func NewRotatingFile(options RotateOptions) (*RotatingFile, error) {
+	if options.Filename == "" {
+		return nil, errors.New("log: rotating file name is empty")
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to rotate log file, %v\n", err)
+		}
+	}
+	r := &RotatingFile{
+		options: options,
+		now:     time.Now,
+		millCh:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.millRun()
+
+	if options.ReopenOnSIGHUP {
+		r.signalCh = make(chan os.Signal, 1)
+		signal.Notify(r.signalCh, syscall.SIGHUP)
+		r.wg.Add(1)
+		go r.signalRun()
+	}
+	return r, nil
+}
+
+// Write implements io.Writer, rotating the file before the write when needed.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if r.file == nil {
+		if err := r.openExistingOrNew(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// shouldRotate 超出大小或跨过时间周期时需要切割，空文件不按大小切割
+func (r *RotatingFile) shouldRotate(writeLen int64) bool {
+	if r.options.MaxSize > 0 && r.size > 0 && r.size+writeLen > r.options.MaxSize {
+		return true
+	}
+	return !r.nextRotation.IsZero() && !r.now().Before(r.nextRotation)
+}
+
+// Rotate closes the current file, renames it to a backup and opens a new file.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrWriterClosed
+	}
+	return r.rotate()
+}
+
+// Reopen closes and reopens the file, e.g. after logrotate moved it away.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return ErrWriterClosed
+	}
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	return r.openExistingOrNew()
+}
+
+// Close closes the file and stops the background goroutines, waiting for a running cleanup.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	err := r.closeFile()
+	r.mu.Unlock()
+
+	if r.signalCh != nil {
+		signal.Stop(r.signalCh)
+	}
+	close(r.done)
+	r.wg.Wait()
+	return err
+}
+
+// rotate 切割文件，调用方需要持有锁
+func (r *RotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(r.options.Filename); err == nil {
+		if err = os.Rename(r.options.Filename, r.backupName(r.backupTime())); err != nil {
+			return fmt.Errorf("can't rename log file: %w", err)
+		}
+	}
+	if err := r.openNew(); err != nil {
+		return err
+	}
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// openExistingOrNew 打开已有的日志文件继续追加，根据文件的修改时间计算下一次切割的时间
+func (r *RotatingFile) openExistingOrNew() error {
+	info, err := os.Stat(r.options.Filename)
+	if os.IsNotExist(err) {
+		return r.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %w", err)
+	}
+	file, err := os.OpenFile(r.options.Filename, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	r.nextRotation = r.nextBoundary(info.ModTime())
+	return nil
+}
+
+// openNew 创建新的日志文件
+func (r *RotatingFile) openNew() error {
+	if err := os.MkdirAll(filepath.Dir(r.options.Filename), 0755); err != nil {
+		return fmt.Errorf("can't make directories for new log file: %w", err)
+	}
+	file, err := os.OpenFile(r.options.Filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open new log file: %w", err)
+	}
+	r.file = file
+	r.size = 0
+	r.nextRotation = r.nextBoundary(r.now())
+	return nil
+}
+
+func (r *RotatingFile) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// nextBoundary 返回 t 之后的下一个整点或零点，不按时间切割时返回零值
+func (r *RotatingFile) nextBoundary(t time.Time) time.Time {
+	start := r.periodStart(t)
+	switch r.options.Interval {
+	case RotateHourly:
+		return start.Add(time.Hour)
+	case RotateDaily:
+		return start.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// periodStart 返回 t 所在周期的开始时间，即整点或零点
+func (r *RotatingFile) periodStart(t time.Time) time.Time {
+	t = t.In(time.Local)
+	switch r.options.Interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// backupTime 备份文件名中的时间：按时间切割时为当前文件所属周期的开始时间，
+// 即使切割发生在下一个周期，备份名也对应其中日志的日期；否则为切割的时间
+func (r *RotatingFile) backupTime() time.Time {
+	if r.nextRotation.IsZero() {
+		return r.now()
+	}
+	return r.periodStart(r.nextRotation.Add(-time.Nanosecond))
+}
+
+// prefixAndExt 返回备份文件名的前缀与扩展名，例如 app.log 返回 "app-" 与 ".log"
+func (r *RotatingFile) prefixAndExt() (string, string) {
+	filename := filepath.Base(r.options.Filename)
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "-", ext
+}
+
+// backupName 返回新备份文件的路径，同一时间已有备份时在时间后加上比它们都大的序号，
+// 例如 app-2021-03-04T05-06-07.000.1.log，避免覆盖之前的备份，并保证新的备份排在后面
+func (r *RotatingFile) backupName(t time.Time) string {
+	prefix, ext := r.prefixAndExt()
+	stamp := t.In(time.Local).Format(backupTimeFormat)
+	seq := 0
+	files, _ := r.oldLogFiles()
+	for _, f := range files {
+		if f.timestamp.Format(backupTimeFormat) == stamp && f.seq >= seq {
+			seq = f.seq + 1
+		}
+	}
+	name := prefix + stamp
+	if seq > 0 {
+		name += "." + strconv.Itoa(seq)
+	}
+	return filepath.Join(filepath.Dir(r.options.Filename), name+ext)
+}
+
+// logInfo 一个备份文件，seq 为同一时间的备份的序号
+type logInfo struct {
+	timestamp time.Time
+	seq       int
+	name      string
+}
+
+// oldLogFiles 返回所有备份文件，按时间从新到旧排序
+func (r *RotatingFile) oldLogFiles() ([]logInfo, error) {
+	dir := filepath.Dir(r.options.Filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("can't read log file directory: %w", err)
+	}
+	prefix, ext := r.prefixAndExt()
+	var files []logInfo
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		ts := strings.TrimSuffix(name, compressSuffix)
+		if !strings.HasPrefix(ts, prefix) || !strings.HasSuffix(ts, ext) {
+			continue
+		}
+		ts = strings.TrimSuffix(strings.TrimPrefix(ts, prefix), ext)
+		seq := 0
+		if len(ts) > len(backupTimeFormat) {
+			// 同一时间的备份带有序号，例如 2021-03-04T05-06-07.000.1
+			if ts[len(backupTimeFormat)] != '.' {
+				continue
+			}
+			n, err := strconv.Atoi(ts[len(backupTimeFormat)+1:])
+			if err != nil || n <= 0 {
+				continue
+			}
+			ts, seq = ts[:len(backupTimeFormat)], n
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, ts, time.Local)
+		if err != nil {
+			continue
+		}
+		files = append(files, logInfo{timestamp: t, seq: seq, name: filepath.Join(dir, name)})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].timestamp.Equal(files[j].timestamp) {
+			return files[i].timestamp.After(files[j].timestamp)
+		}
+		return files[i].seq > files[j].seq
+	})
+	return files, nil
+}
+
+// millRun 后台清理goroutine，每次切割后执行一次
+func (r *RotatingFile) millRun() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.millCh:
+			if err := r.millRunOnce(); err != nil {
+				r.options.OnError(err)
+			}
+		case <-r.done:
+			// 关闭前完成已经触发的清理
+			select {
+			case <-r.millCh:
+				if err := r.millRunOnce(); err != nil {
+					r.options.OnError(err)
+				}
+			default:
+			}
+			return
+		}
+	}
+}
+
+// millRunOnce 按数量与时间删除过期的备份，并压缩剩余的备份
+func (r *RotatingFile) millRunOnce() error {
+	if r.options.MaxBackups == 0 && r.options.MaxAge == 0 && !r.options.Compress {
+		return nil
+	}
+	files, err := r.oldLogFiles()
+	if err != nil {
+		return err
+	}
+
+	var remove, remaining []logInfo
+	cutoff := time.Time{}
+	if r.options.MaxAge > 0 {
+		cutoff = r.now().Add(-r.options.MaxAge)
+	}
+	for i, f := range files {
+		if r.options.MaxBackups > 0 && i >= r.options.MaxBackups ||
+			!cutoff.IsZero() && f.timestamp.Before(cutoff) {
+			remove = append(remove, f)
+			continue
+		}
+		remaining = append(remaining, f)
+	}
+
+	var errs []string
+	for _, f := range remove {
+		if err := os.Remove(f.name); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+		}
+	}
+	if r.options.Compress {
+		for _, f := range remaining {
+			if strings.HasSuffix(f.name, compressSuffix) {
+				continue
+			}
+			if err := compressLogFile(f.name, f.name+compressSuffix); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// compressLogFile 将src压缩为dst并删除src
+func compressLogFile(src, dst string) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	gzf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open compressed log file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			gzf.Close()
+			_ = os.Remove(dst)
+		}
+	}()
+
+	gz := gzip.NewWriter(gzf)
+	if _, err = io.Copy(gz, f); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = gzf.Close(); err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(src)
+}
+
+// signalRun 收到SIGHUP时重新打开日志文件
+func (r *RotatingFile) signalRun() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.signalCh:
+			if err := r.Reopen(); err != nil && err != ErrWriterClosed {
+				r.options.OnError(err)
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
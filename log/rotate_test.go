@@ -0,0 +1,242 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeClock 可以手动推进的时钟
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// newTestRotatingFile 创建使用假时钟的 RotatingFile
+func newTestRotatingFile(t *testing.T, options RotateOptions, clock *fakeClock) *RotatingFile {
+	r, err := NewRotatingFile(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = clock.Now
+	return r
+}
+
+// listDir 返回目录中按名称排序的文件名
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeString(t *testing.T, w io.Writer, s string) {
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRotateBySize 测试按大小切割、保留指定数量的备份并压缩
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)}
+	r := newTestRotatingFile(t, RotateOptions{
+		Filename:   filepath.Join(dir, "app.log"),
+		MaxSize:    10,
+		MaxBackups: 2,
+		Compress:   true,
+	}, clock)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		writeString(t, r, line)
+		clock.Add(time.Second)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"app-2021-03-04T05-06-09.000.log.gz", "app-2021-03-04T05-06-10.000.log.gz", "app.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("备份文件不正确: %v", got)
+	}
+	if readFile(t, filepath.Join(dir, "app.log")) != "fourth\n" {
+		t.Fatal("当前文件应该只包含最后一条日志")
+	}
+
+	f, _ := os.Open(filepath.Join(dir, want[1]))
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "third\n" {
+		t.Fatalf("压缩的备份内容不正确: %q", data)
+	}
+}
+
+// TestRotateByTime 测试按天与按小时切割，已有文件跨过周期时在第一次写入时切割
+func TestRotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	clock := &fakeClock{t: time.Date(2021, 3, 4, 23, 59, 0, 0, time.Local)}
+	r := newTestRotatingFile(t, RotateOptions{Filename: name, Interval: RotateDaily}, clock)
+
+	writeString(t, r, "day1\n")
+	clock.Add(30 * time.Second)
+	writeString(t, r, "day1 again\n")
+	clock.Add(time.Minute)
+	writeString(t, r, "day2\n")
+	_ = r.Close()
+
+	// 备份名使用其中日志所属的日期，而不是切割的时间
+	if got := listDir(t, dir); len(got) != 2 || got[0] != "app-2021-03-04T00-00-00.000.log" {
+		t.Fatalf("跨天时应该切割: %v", got)
+	}
+	if readFile(t, filepath.Join(dir, "app-2021-03-04T00-00-00.000.log")) != "day1\nday1 again\n" || readFile(t, name) != "day2\n" {
+		t.Fatal("切割前后的内容不正确")
+	}
+
+	// 已有文件的修改时间在上一个小时，重新打开后第一次写入就切割
+	old := time.Date(2021, 3, 5, 0, 10, 0, 0, time.Local)
+	_ = os.Chtimes(name, old, old)
+	clock.Add(time.Hour)
+	r = newTestRotatingFile(t, RotateOptions{Filename: name, Interval: RotateHourly, MaxSize: 1 << 20}, clock)
+	writeString(t, r, "hour2\n")
+	_ = r.Close()
+	if got := listDir(t, dir); len(got) != 3 || got[1] != "app-2021-03-05T00-00-00.000.log" || readFile(t, name) != "hour2\n" {
+		t.Fatalf("跨小时的已有文件应该切割: %v", got)
+	}
+}
+
+// TestRotateSameTime 测试同一时间多次切割时备份名加上序号，不会覆盖之前的备份
+func TestRotateSameTime(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2021, 3, 4, 5, 6, 7, 0, time.Local)}
+	r := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxBackups: 2}, clock)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		writeString(t, r, line)
+		if err := r.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = r.Close()
+
+	want := []string{"app-2021-03-04T05-06-07.000.1.log", "app-2021-03-04T05-06-07.000.2.log", "app.log"}
+	if got := listDir(t, dir); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("同一时间的备份应该加上序号，并按序号保留最新的备份: %v", got)
+	}
+	if readFile(t, filepath.Join(dir, want[0])) != "second\n" || readFile(t, filepath.Join(dir, want[1])) != "third\n" {
+		t.Fatal("备份的内容不正确")
+	}
+}
+
+// TestRotateMaxAge 测试删除超过保留时间的备份
+func TestRotateMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2021, 3, 10, 12, 0, 0, 0, time.Local)}
+	for _, backup := range []string{
+		"app-2021-03-01T00-00-00.000.log",
+		"app-2021-03-08T00-00-00.000.log.gz",
+		"app-2021-03-09T00-00-00.000.log",
+		"other.log",
+	} {
+		_ = os.WriteFile(filepath.Join(dir, backup), []byte("x"), 0644)
+	}
+
+	r := newTestRotatingFile(t, RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxAge: 48 * time.Hour}, clock)
+	writeString(t, r, "now\n")
+	if err := r.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	_ = r.Close()
+
+	want := "app-2021-03-09T00-00-00.000.log,app-2021-03-10T12-00-00.000.log,app.log,other.log"
+	if got := listDir(t, dir); strings.Join(got, ",") != want {
+		t.Fatalf("应该删除超过两天的备份: %v", got)
+	}
+}
+
+// TestRotateReopen 测试logrotate移走文件后收到SIGHUP重新打开
+func TestRotateReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	r := newTestRotatingFile(t, RotateOptions{Filename: name, ReopenOnSIGHUP: true}, &fakeClock{t: time.Now()})
+	defer r.Close()
+
+	writeString(t, r, "before\n")
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := os.FindProcess(os.Getpid())
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("不支持发送SIGHUP: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(name); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("收到SIGHUP后应该重新打开日志文件")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	writeString(t, r, "after\n")
+	if readFile(t, name+".1") != "before\n" || readFile(t, name) != "after\n" {
+		t.Fatal("重新打开后应该写入新文件")
+	}
+}
+
+// TestLoggerRotatingLogFile 测试Logger使用切割文件作为日志文件
+func TestLoggerRotatingLogFile(t *testing.T) {
+	dir := t.TempDir()
+	var out strings.Builder
+	logger := newLogger()
+	logger.Out = &out
+	if err := logger.SetRotatingLogFile(RotateOptions{Filename: filepath.Join(dir, "app.log"), MaxSize: 40}); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("first")
+	logger.Info("second")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := listDir(t, dir); len(got) != 2 || !strings.Contains(readFile(t, filepath.Join(dir, "app.log")), "second") {
+		t.Fatalf("日志文件应该按大小切割: %v", got)
+	}
+}
@@ -0,0 +1,112 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+const (
+	red    = 31
+	yellow = 33
+	blue   = 36
+	gray   = 37
+
+	// defaultTextTimestampFormat TextFormatter 默认的时间格式
+	defaultTextTimestampFormat = "2006-01-02 15:04:05"
+)
+
+// baseTimestamp 程序启动时间，终端输出没有开启 FullTimestamp 时显示相对于它的秒数
+var baseTimestamp = time.Now()
+
+// TextFormatter formats logs into text, e.g.
+//
+//	[2006-01-02 15:04:05][info] user login user=tom id=7
+type TextFormatter struct {
+	// Set to true to bypass checking for a TTY before outputting colors.
+	ForceColors bool
+
+	// Force disabling colors.
+	DisableColors bool
+
+	// Disable timestamp logging. useful when output is redirected to logging
+	// system that already adds timestamps.
+	DisableTimestamp bool
+
+	// Enable logging the full timestamp when a TTY is attached instead of just
+	// the time passed since beginning of execution. The log file and sinks always
+	// get the full timestamp without colors.
+	FullTimestamp bool
+
+	// TimestampFormat to use for display when a full timestamp is printed,
+	// defaults to "2006-01-02 15:04:05".
+	TimestampFormat string
+
+	// SortFields sorts the fields by key, by default fields keep the order in which they were added.
+	SortFields bool
+}
+
+// isColored 终端输出或 ForceColors 时带颜色，为文件与sink格式化时总是不带颜色
+func (f *TextFormatter) isColored(entry *Entry) bool {
+	if entry.plain {
+		return false
+	}
+	return f.ForceColors || (entry.terminal && !f.DisableColors)
+}
+
+// forcesColors 判断格式化器是否在非终端输出中也使用颜色
+func forcesColors(formatter Formatter) bool {
+	f, ok := formatter.(*TextFormatter)
+	return ok && f.ForceColors
+}
+
+// Format renders a single log entry
+func (f *TextFormatter) Format(entry *Entry) ([]byte, error) {
+	b := &bytes.Buffer{}
+	colored := f.isColored(entry)
+	if !f.DisableTimestamp {
+		switch {
+		case colored && !f.FullTimestamp:
+			fmt.Fprintf(b, "[%04d]", int(entry.Time.Sub(baseTimestamp)/time.Second))
+		default:
+			timestampFormat := f.TimestampFormat
+			if timestampFormat == "" {
+				timestampFormat = defaultTextTimestampFormat
+			}
+			b.WriteString("[" + entry.Time.Format(timestampFormat) + "]")
+		}
+	}
+
+	level := "[" + entry.Level.String() + "]"
+	if colored {
+		level = fmt.Sprintf("\x1b[%dm%s\x1b[0m", levelColor(entry.Level), level)
+	}
+	b.WriteString(level + " " + entry.Message)
+
+	for _, k := range entry.sortedKeys(f.SortFields) {
+		if colored {
+			fmt.Fprintf(b, " \x1b[%dm%s\x1b[0m=%s", levelColor(entry.Level), k, fieldValue(entry.Data[k]))
+		} else {
+			b.WriteString(" " + k + "=" + fieldValue(entry.Data[k]))
+		}
+	}
+	if entry.err != "" {
+		b.WriteString(" " + FieldKeyLogError + "=" + fieldValue(entry.err))
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// levelColor 各级别在终端中的颜色
+func levelColor(level Level) int {
+	switch level {
+	case DebugLevel, TraceLevel:
+		return gray
+	case WarnLevel:
+		return yellow
+	case ErrorLevel, FatalLevel, PanicLevel:
+		return red
+	default:
+		return blue
+	}
+}